@@ -7,24 +7,60 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/mullakhmetov/status-board/internal/notify"
 	"github.com/mullakhmetov/status-board/internal/rest"
+	"github.com/mullakhmetov/status-board/internal/sites"
 )
 
 func main() {
-	var port, timeout, askRate int
-	var metrics bool
-	var sitesPath string
+	var port, timeout, askRate, historyRetention, latencyWindow, webhookTimeout, webhookRetries int
+	var concurrency, hostConcurrency int
+	var retries, retryBackoff, failThreshold, recoverThreshold int
+	var certWarning int
+	var jitter float64
+	var metrics, spread bool
+	var sitesPath, dbPath, historyPath, webhooks string
 
 	flag.IntVar(&port, "port", 8080, "server listen port")
 	flag.IntVar(&timeout, "timeout", 5, "service ask timeout in seconds")
 	flag.IntVar(&askRate, "check_rate", 60, "service cheks rate in seconds")
 	flag.BoolVar(&metrics, "metrics", false, "enable metrics")
-	flag.StringVar(&sitesPath, "sites_path", "", "abs path to sites file")
+	flag.StringVar(&sitesPath, "sites_path", "", "abs path to sites file (plain text, yaml or json)")
+	flag.StringVar(&dbPath, "db_path", "", "abs path to db file to persist sites and their status")
+	flag.StringVar(&historyPath, "history_path", "", "abs path to db file to store checks history, kept in memory if empty")
+	flag.IntVar(&historyRetention, "history_retention", 0, "checks history retention in hours, 720 with history_path and 24 in memory by default")
+	flag.IntVar(&latencyWindow, "latency_window", 60*60, "latency statistics window in seconds")
+	flag.IntVar(&concurrency, "concurrency", 0, "max simultaneous checks, unlimited if zero")
+	flag.IntVar(&hostConcurrency, "host_concurrency", 0, "max simultaneous checks per host, unlimited if zero")
+	flag.BoolVar(&spread, "spread", false, "spread initial checks evenly across check rate")
+	flag.Float64Var(&jitter, "jitter", 0.1, "fraction of check interval randomly added or subtracted, within [0, 1)")
+	flag.IntVar(&retries, "retries", 0, "failed request retries count within a check")
+	flag.IntVar(&retryBackoff, "retry_backoff", 500, "delay before the first retry in milliseconds, doubled for every next one")
+	flag.IntVar(&failThreshold, "fail_threshold", 1, "consecutive failed checks to declare site down")
+	flag.IntVar(&recoverThreshold, "recover_threshold", 1, "consecutive successful checks to declare site up")
+	flag.IntVar(&certWarning, "cert_warning", 0, "days before certificate expiry to report site as degraded, disabled if zero")
+	flag.StringVar(&webhooks, "webhooks", "", "comma separated urls to post sites state changes to")
+	flag.IntVar(&webhookTimeout, "webhook_timeout", 5, "webhook request timeout in seconds")
+	flag.IntVar(&webhookRetries, "webhook_retries", 3, "webhook delivery retries count")
 	flag.Parse()
 
+	if jitter < 0 || jitter >= 1 {
+		fmt.Printf("Invalid jitter %v, fraction within [0, 1) expected\n", jitter)
+		os.Exit(1)
+	}
+
+	if historyRetention <= 0 {
+		// every check result is kept in process memory without history db
+		historyRetention = 24
+		if historyPath != "" {
+			historyRetention = 30 * 24
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		// catch signal and invoke graceful termination
@@ -41,9 +77,31 @@ func main() {
 		ChecksRate:   time.Second * time.Duration(askRate),
 		StoreMetrics: metrics,
 		SitesPath:    sitesPath,
+		DbPath:       dbPath,
+
+		HistoryPath:      historyPath,
+		HistoryRetention: time.Hour * time.Duration(historyRetention),
+		LatencyWindow:    time.Second * time.Duration(latencyWindow),
+
+		Concurrency:     concurrency,
+		HostConcurrency: hostConcurrency,
+		SpreadChecks:    spread,
+		Jitter:          jitter,
+
+		Retries:      retries,
+		RetryBackoff: time.Millisecond * time.Duration(retryBackoff),
+		Hysteresis:   sites.Hysteresis{Down: failThreshold, Up: recoverThreshold},
+		CertWarning:  24 * time.Hour * time.Duration(certWarning),
+
+		Webhooks: splitList(webhooks),
+		WebhookOpts: notify.WebhookOpts{
+			Timeout: time.Second * time.Duration(webhookTimeout),
+			Retries: webhookRetries,
+		},
 	})
 	if err != nil {
 		fmt.Println(err.Error())
+		os.Exit(1)
 	}
 
 	err = server.Run(ctx)
@@ -54,3 +112,14 @@ func main() {
 
 	log.Printf("[INFO] terminated")
 }
+
+// splitList splits comma separated flag value omitting empty items
+func splitList(value string) []string {
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
@@ -3,8 +3,15 @@ module github.com/mullakhmetov/status-board
 go 1.13
 
 require (
-	github.com/boltdb/bolt v1.3.1
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gin-gonic/gin v1.5.0
+	github.com/golang/protobuf v1.3.2
+	github.com/gorilla/websocket v1.4.2
+	github.com/prometheus/client_model v0.2.0
 	github.com/prometheus/common v0.9.1
 	github.com/stretchr/testify v1.5.1
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/net v0.0.0-20190613194153-d28f0bde5980
+	google.golang.org/grpc v1.27.1
+	gopkg.in/yaml.v2 v2.2.4
 )
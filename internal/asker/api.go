@@ -1,7 +1,11 @@
 package asker
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,13 +13,27 @@ import (
 func RegisterHandlers(r *gin.Engine, service Service) {
 	res := resource{service}
 
+	r.GET("/status", res.List)
 	r.GET("/status/min", res.Min)
 	r.GET("/status/max", res.Max)
 	r.GET("/status/random", res.Random)
 
 	r.GET("/status/site/:site", res.CheckStatus)
+	r.GET("/status/site/:site/uptime", res.Uptime)
+	r.GET("/status/site/:site/latency", res.Latency)
+
+	r.GET("/certificates", res.Certificates)
 }
 
+// defaultListLimit and maxListLimit bound a number of resources per list page
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// defaultCertificatesDays is a period certificates are listed as expiring within by default
+const defaultCertificatesDays = 30
+
 type resource struct {
 	service Service
 }
@@ -52,6 +70,43 @@ func (r *resource) Random(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// List returns page of resources filtered by `alive`, `name` pattern and `tag` query params
+// and ordered by `sort` param, `-` prefix reverses order. Next page is requested with `cursor` param
+func (r *resource) List(c *gin.Context) {
+	q := ListQuery{
+		Name:   c.Query("name"),
+		Tags:   c.QueryArray("tag"),
+		Sort:   strings.TrimPrefix(c.Query("sort"), "-"),
+		Desc:   strings.HasPrefix(c.Query("sort"), "-"),
+		Limit:  defaultListLimit,
+		Cursor: c.Query("cursor"),
+	}
+	if v := c.Query("alive"); v != "" {
+		alive, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf("Invalid alive %s, boolean expected", v))
+			return
+		}
+		q.Alive = &alive
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf("Invalid limit %s, number from 1 to %d expected", v, maxListLimit))
+			return
+		}
+		q.Limit = limit
+	}
+
+	res, err := r.service.List(c, q)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
 func (r *resource) CheckStatus(c *gin.Context) {
 	name := c.Param("site")
 	res, err := r.service.Get(c, name)
@@ -63,10 +118,54 @@ func (r *resource) CheckStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+func (r *resource) Uptime(c *gin.Context) {
+	name := c.Param("site")
+	res, err := r.service.Uptime(c, name)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (r *resource) Latency(c *gin.Context) {
+	name := c.Param("site")
+	res, err := r.service.Latency(c, name)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Certificates lists certificates expiring within `days` query param
+func (r *resource) Certificates(c *gin.Context) {
+	days := defaultCertificatesDays
+	if v := c.Query("days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, fmt.Sprintf("Invalid days %s, non-negative number expected", v))
+			return
+		}
+	}
+
+	res, err := r.service.Certificates(c, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
 func (r *resource) handleError(c *gin.Context, err error) {
 	switch v := err.(type) {
 	case *NotFoundError:
 		c.JSON(http.StatusNotFound, v.Error())
+	case *ValidationError:
+		c.JSON(http.StatusBadRequest, v.Error())
 	case *NoResponse:
 		c.JSON(http.StatusNoContent, v.Error())
 	default:
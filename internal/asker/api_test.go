@@ -1,11 +1,14 @@
 package asker
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mullakhmetov/status-board/internal/history"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -54,6 +57,83 @@ func TestRandom(t *testing.T) {
 	ms.AssertExpectations(t)
 }
 
+func TestUptime(t *testing.T) {
+	router, ms := setupRouter()
+
+	ms.On("Uptime", mock.AnythingOfType("*gin.Context"), "some-site").Return([]history.Uptime{{Window: "1h", Percent: 100, Checks: 1}}, nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/status/site/some-site/uptime", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	ms.AssertExpectations(t)
+
+	ms.On("Uptime", mock.AnythingOfType("*gin.Context"), "unknown").Return([]history.Uptime(nil), &NotFoundError{"unknown"})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/status/site/unknown/uptime", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func TestLatency(t *testing.T) {
+	router, ms := setupRouter()
+
+	ms.On("Latency", mock.AnythingOfType("*gin.Context"), "some-site").Return(LatencyStats{Name: "some-site"}, nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/status/site/some-site/latency", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	ms.AssertExpectations(t)
+}
+
+func TestCertificates(t *testing.T) {
+	router, ms := setupRouter()
+
+	ms.On("Certificates", mock.AnythingOfType("*gin.Context"), 30*24*time.Hour).Return([]CertificateStatus{{Name: "some-site"}}, nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/certificates", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	ms.On("Certificates", mock.AnythingOfType("*gin.Context"), 7*24*time.Hour).Return([]CertificateStatus{}, nil)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/certificates?days=7", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	ms.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/certificates?days=week", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestList(t *testing.T) {
+	router, ms := setupRouter()
+
+	ms.On("List", mock.AnythingOfType("*gin.Context"), ListQuery{Tags: []string{}, Limit: 50}).Return(ListPage{Total: 0}, nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/status", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	alive := true
+	q := ListQuery{Alive: &alive, Name: "*.com", Tags: []string{"prod", "search"}, Sort: SortLatency, Desc: true, Limit: 10, Cursor: "abc"}
+	ms.On("List", mock.AnythingOfType("*gin.Context"), q).Return(ListPage{}, nil)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/status?alive=true&name=*.com&tag=prod&tag=search&sort=-latency&limit=10&cursor=abc", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	ms.AssertExpectations(t)
+
+	ms.On("List", mock.AnythingOfType("*gin.Context"), ListQuery{Tags: []string{}, Sort: "uptime", Limit: 50}).Return(ListPage{}, &ValidationError{fmt.Errorf("Invalid sort uptime")})
+	for _, query := range []string{"alive=maybe", "limit=0", "limit=1000", "sort=uptime"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/status?"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
 func setupRouter() (*gin.Engine, *MockedService) {
 	r := gin.Default()
 	ms := new(MockedService)
@@ -7,6 +7,9 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/mullakhmetov/status-board/internal/history"
+	"github.com/mullakhmetov/status-board/internal/sites"
 )
 
 type NotFoundError struct {
@@ -25,9 +28,64 @@ func (e *NoResponse) Error() string {
 
 // Response represents resource availability status
 type Response struct {
-	Name    string
-	Alive   bool
-	Latency time.Duration
+	Name      string
+	Alive     bool
+	Latency   time.Duration
+	CheckedAt time.Time
+	// ChangedAt is a time resource state last changed
+	ChangedAt time.Time
+	// Error is the last failed check reason
+	Error string `json:",omitempty"`
+	// QueueWait is a time the last check waited for concurrency limits, it isn't included in Latency
+	QueueWait time.Duration
+	// Timings is the last check latency breakdown by request phases
+	Timings sites.Timings
+	// Addresses are the last resolved answers of dns resource
+	Addresses []string `json:",omitempty"`
+	// ConsecutiveFailures is a number of the latest checks failed in a row
+	ConsecutiveFailures int
+	// Degraded is set for available resource with certificate expiring soon
+	Degraded bool `json:",omitempty"`
+	// Certificate is the last known TLS certificate of https resource
+	Certificate *CertificateStatus `json:",omitempty"`
+	// Uptime is a percent of successful checks by rolling window, e.g. "24h": 99.9.
+	// It's set in single resource response only
+	Uptime map[string]float64 `json:",omitempty"`
+}
+
+// State returns resource availability state
+func (r Response) State() sites.State {
+	return sites.Status{CheckedAt: r.CheckedAt, Alive: r.Alive, Degraded: r.Degraded}.State()
+}
+
+// CertificateStatus is a resource certificate summary
+type CertificateStatus struct {
+	Name string `json:",omitempty"`
+	sites.Certificate
+	DaysToExpiry int
+}
+
+// Event is emitted on every recorded resource check result
+type Event struct {
+	Site     string
+	Tags     []string
+	Previous sites.State
+	Current  sites.State
+	Result   sites.Result
+}
+
+// Changed reports whether resource state has changed.
+// The first successful check of a resource isn't considered a change
+func (e Event) Changed() bool {
+	if e.Previous == sites.StateUnknown {
+		return e.Current != sites.StateUp
+	}
+	return e.Previous != e.Current
+}
+
+// Listener receives asker events. OnEvent is called from check goroutines and must not block
+type Listener interface {
+	OnEvent(ev Event)
 }
 
 // Service defines interface to check resources availability
@@ -39,6 +97,10 @@ type Service interface {
 	GetMin(ctx context.Context) (Response, error)
 	GetMax(ctx context.Context) (Response, error)
 	GetRandom(ctx context.Context) (Response, error)
+	List(ctx context.Context, q ListQuery) (ListPage, error)
+	Uptime(ctx context.Context, name string) ([]history.Uptime, error)
+	Latency(ctx context.Context, name string) (LatencyStats, error)
+	Certificates(ctx context.Context, within time.Duration) ([]CertificateStatus, error)
 
 	Close()
 }
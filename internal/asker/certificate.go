@@ -0,0 +1,39 @@
+package asker
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// certificate summarizes peer certificate chain, nil if connection isn't secure
+func certificate(state *tls.ConnectionState) *sites.Certificate {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	cert := &sites.Certificate{
+		Subject:  leaf.Subject.String(),
+		Issuer:   leaf.Issuer.String(),
+		DNSNames: leaf.DNSNames,
+		NotAfter: leaf.NotAfter,
+	}
+	// chain is valid until any of it's certificates expires
+	for _, c := range state.PeerCertificates[1:] {
+		if c.NotAfter.Before(cert.NotAfter) {
+			cert.NotAfter = c.NotAfter
+		}
+	}
+
+	return cert
+}
+
+func newCertificateStatus(name string, cert *sites.Certificate, now time.Time) CertificateStatus {
+	return CertificateStatus{
+		Name:         name,
+		Certificate:  *cert,
+		DaysToExpiry: cert.DaysToExpiry(now),
+	}
+}
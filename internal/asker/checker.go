@@ -0,0 +1,75 @@
+package asker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// Checker checks availability of sites with particular url scheme.
+// Check must respect ctx cancellation, result is recorded by asker
+type Checker interface {
+	Check(ctx context.Context, site *sites.Site) sites.Result
+}
+
+// CheckerFunc is an adapter to use ordinary function as Checker
+type CheckerFunc func(ctx context.Context, site *sites.Site) sites.Result
+
+// Check calls f(ctx, site)
+func (f CheckerFunc) Check(ctx context.Context, site *sites.Site) sites.Result {
+	return f(ctx, site)
+}
+
+// check runs site checker selected by url scheme within site timeout
+func (a *httpAsker) check(ctx context.Context, site *sites.Site) (res sites.Result) {
+	checker, ok := a.checkers[site.Url.Scheme]
+	if !ok {
+		return sites.Result{
+			CheckedAt: time.Now(),
+			Error:     fmt.Sprintf("unsupported scheme %q", site.Url.Scheme),
+		}
+	}
+
+	if site.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, site.Timeout)
+		defer cancel()
+	}
+
+	res = checker.Check(ctx, site)
+	if !res.Alive {
+		return res
+	}
+	if err := site.Assertions.CheckLatency(res.Latency); err != nil {
+		res.Alive = false
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// bindConn limits conn reads and writes by ctx deadline or timeout from now if ctx has none,
+// and closes conn on ctx cancel to unblock them. Returned unbind must be called once conn isn't used
+func bindConn(ctx context.Context, conn net.Conn, timeout time.Duration) (unbind func(), err error) {
+	deadline, ok := ctx.Deadline()
+	if !ok && timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }, nil
+}
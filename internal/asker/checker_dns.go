@@ -0,0 +1,131 @@
+package asker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// dnsChecker resolves dns://[resolver[:port]]/name?type=A sites. System resolver is used
+// if url has no host, record type is A by default
+type dnsChecker struct {
+	timeout time.Duration
+}
+
+func newDNSChecker(timeout time.Duration) *dnsChecker {
+	return &dnsChecker{timeout: timeout}
+}
+
+func (c *dnsChecker) Check(ctx context.Context, site *sites.Site) (res sites.Result) {
+	res.CheckedAt = time.Now()
+
+	name := strings.Trim(site.Url.Path, "/")
+	if name == "" {
+		res.Error = "name is required"
+		return res
+	}
+	// absolute name isn't completed with search domains
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	qtype := strings.ToUpper(site.Url.Query().Get("type"))
+	if qtype == "" {
+		qtype = "A"
+	}
+
+	start := time.Now()
+	answers, err := lookup(ctx, c.resolver(site.Url.Host), qtype, name)
+	res.Latency = time.Since(start)
+	res.Timings.DNS = res.Latency
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Addresses = answers
+
+	if err := site.Assertions.CheckAnswers(answers); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Alive = true
+	return res
+}
+
+// resolver returns resolver querying addr, 53 port is used by default
+func (c *dnsChecker) resolver(addr string) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: c.timeout}
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// lookup resolves name records of qtype and returns sorted answers
+func lookup(ctx context.Context, r *net.Resolver, qtype, name string) ([]string, error) {
+	var answers []string
+	switch qtype {
+	case "A", "AAAA":
+		addrs, err := r.LookupIPAddr(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failed: %v", err)
+		}
+		for _, addr := range addrs {
+			if (addr.IP.To4() != nil) == (qtype == "A") {
+				answers = append(answers, addr.IP.String())
+			}
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failed: %v", err)
+		}
+		answers = append(answers, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failed: %v", err)
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case "NS":
+		nss, err := r.LookupNS(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failed: %v", err)
+		}
+		for _, ns := range nss {
+			answers = append(answers, ns.Host)
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failed: %v", err)
+		}
+		answers = append(answers, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", qtype)
+	}
+
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("no %s records found", qtype)
+	}
+	sort.Strings(answers)
+
+	return answers, nil
+}
@@ -0,0 +1,126 @@
+package asker
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// serveDNS answers udp dns queries with records by name, unknown names are answered with NXDOMAIN
+func serveDNS(t *testing.T, records map[string][]dnsmessage.Resource) (addr string, teardown func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+				continue
+			}
+			q := req.Questions[0]
+
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.ID, Response: true, Authoritative: true},
+				Questions: req.Questions,
+			}
+			rrs, ok := records[q.Name.String()]
+			if !ok {
+				resp.RCode = dnsmessage.RCodeNameError
+			}
+			for _, rr := range rrs {
+				if rr.Header.Type == q.Type {
+					rr.Header.Name = q.Name
+					rr.Header.Class = dnsmessage.ClassINET
+					resp.Answers = append(resp.Answers, rr)
+				}
+			}
+
+			packed, err := resp.Pack()
+			assert.NoError(t, err)
+			_, _ = conn.WriteTo(packed, from)
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func TestDNSChecker(t *testing.T) {
+	mx, err := dnsmessage.NewName("mx.example.test.")
+	assert.NoError(t, err)
+
+	addr, teardown := serveDNS(t, map[string][]dnsmessage.Resource{
+		"example.test.": {
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeA, TTL: 60}, Body: &dnsmessage.AResource{A: [4]byte{10, 0, 0, 2}}},
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeA, TTL: 60}, Body: &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}},
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeMX, TTL: 60}, Body: &dnsmessage.MXResource{Pref: 10, MX: mx}},
+			{Header: dnsmessage.ResourceHeader{Type: dnsmessage.TypeTXT, TTL: 60}, Body: &dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}}},
+		},
+	})
+	defer teardown()
+
+	c := newDNSChecker(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res := c.Check(ctx, checkerSite(t, "dns://"+addr+"/example.test", &sites.Expectation{Answers: []string{"10.0.0.1"}}))
+	assert.True(t, res.Alive, res.Error)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, res.Addresses)
+	assert.True(t, res.Latency > 0)
+	assert.Equal(t, res.Latency, res.Timings.DNS)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr+"/example.test?type=mx", &sites.Expectation{Answers: []string{"MX.example.test"}}))
+	assert.True(t, res.Alive, res.Error)
+	assert.Equal(t, []string{"mx.example.test."}, res.Addresses)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr+"/example.test?type=TXT", nil))
+	assert.True(t, res.Alive, res.Error)
+	assert.Equal(t, []string{"v=spf1 -all"}, res.Addresses)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr+"/example.test", &sites.Expectation{Answers: []string{"10.0.0.3"}}))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "answer 10.0.0.3 not resolved", res.Error)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, res.Addresses)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr+"/example.test?type=AAAA", nil))
+	assert.False(t, res.Alive)
+	assert.True(t, strings.HasPrefix(res.Error, "no AAAA records found"), res.Error)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr+"/missing.test", nil))
+	assert.False(t, res.Alive)
+	assert.True(t, strings.HasPrefix(res.Error, "lookup failed"), res.Error)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr+"/example.test?type=SRV", nil))
+	assert.False(t, res.Alive)
+	assert.Equal(t, `unsupported record type "SRV"`, res.Error)
+
+	res = c.Check(ctx, checkerSite(t, "dns://"+addr, nil))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "name is required", res.Error)
+}
+
+func TestAsker_CheckMaxLatency(t *testing.T) {
+	slow := CheckerFunc(func(ctx context.Context, site *sites.Site) sites.Result {
+		return sites.Result{CheckedAt: time.Now(), Alive: true, Latency: time.Second}
+	})
+	a := &httpAsker{checkers: map[string]Checker{"dns": slow}}
+
+	res := a.check(context.Background(), checkerSite(t, "dns:///example.test", &sites.Expectation{MaxLatency: "100ms"}))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "latency 1s exceeds 100ms", res.Error)
+
+	res = a.check(context.Background(), checkerSite(t, "dns:///example.test", &sites.Expectation{MaxLatency: "2s"}))
+	assert.True(t, res.Alive)
+}
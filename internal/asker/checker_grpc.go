@@ -0,0 +1,71 @@
+package asker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// grpcChecker calls standard grpc.health.v1.Health/Check of grpc://host:port/[service] sites,
+// grpcs scheme is used for TLS. Overall server health is checked if service is omitted
+type grpcChecker struct {
+	// timeout limits connection and call unless ctx has deadline
+	timeout time.Duration
+}
+
+func newGRPCChecker(timeout time.Duration) *grpcChecker {
+	return &grpcChecker{timeout: timeout}
+}
+
+func (c *grpcChecker) Check(ctx context.Context, site *sites.Site) (res sites.Result) {
+	res.CheckedAt = time.Now()
+
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	opts := []grpc.DialOption{grpc.WithBlock(), grpc.FailOnNonTempDialError(true)}
+	if site.Url.Scheme == "grpcs" {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	start := time.Now()
+	conn, err := grpc.DialContext(ctx, site.Url.Host, opts...)
+	if err != nil {
+		res.Error = fmt.Sprintf("connect failed: %v", err)
+		return res
+	}
+	defer conn.Close()
+	res.Timings.Connect = time.Since(start)
+
+	called := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: strings.Trim(site.Url.Path, "/"),
+	})
+	res.Timings.TTFB = time.Since(called)
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Error = fmt.Sprintf("health check failed: %v", err)
+		return res
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		res.Error = fmt.Sprintf("service is %s", resp.Status)
+		return res
+	}
+
+	res.Alive = true
+	return res
+}
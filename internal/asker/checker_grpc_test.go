@@ -0,0 +1,66 @@
+package asker
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func serveGRPCHealth(t *testing.T) (addr string, hs *health.Server, teardown func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := grpc.NewServer()
+	hs = health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+
+	return l.Addr().String(), hs, srv.Stop
+}
+
+func TestGRPCChecker(t *testing.T) {
+	addr, hs, teardown := serveGRPCHealth(t)
+	defer teardown()
+	hs.SetServingStatus("db", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	c := newGRPCChecker(time.Second)
+
+	res := c.Check(context.Background(), checkerSite(t, "grpc://"+addr, nil))
+	assert.True(t, res.Alive, res.Error)
+	assert.True(t, res.Timings.Connect > 0)
+	assert.True(t, res.Timings.TTFB > 0)
+	assert.True(t, res.Latency >= res.Timings.Connect+res.Timings.TTFB)
+
+	res = c.Check(context.Background(), checkerSite(t, "grpc://"+addr+"/db", nil))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "service is NOT_SERVING", res.Error)
+
+	hs.SetServingStatus("db", healthpb.HealthCheckResponse_SERVING)
+	res = c.Check(context.Background(), checkerSite(t, "grpc://"+addr+"/db", nil))
+	assert.True(t, res.Alive, res.Error)
+
+	res = c.Check(context.Background(), checkerSite(t, "grpc://"+addr+"/cache", nil))
+	assert.False(t, res.Alive)
+	assert.True(t, strings.HasPrefix(res.Error, "health check failed"), res.Error)
+	assert.Contains(t, res.Error, "NotFound")
+}
+
+func TestGRPCChecker_Unreachable(t *testing.T) {
+	addr, _, teardown := serveGRPCHealth(t)
+	teardown()
+
+	c := newGRPCChecker(time.Second)
+
+	res := c.Check(context.Background(), checkerSite(t, "grpc://"+addr, nil))
+	assert.False(t, res.Alive)
+	assert.True(t, strings.HasPrefix(res.Error, "connect failed"), res.Error)
+}
@@ -0,0 +1,114 @@
+package asker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// defaultMaxBanner limits banner read if site doesn't limit body size
+const defaultMaxBanner = 64 * 1024
+
+// tcpChecker connects to tcp://host:port sites. Site body is sent once connected and
+// received banner is verified against site body assertions if any
+type tcpChecker struct {
+	dialer net.Dialer
+	// timeout limits connection and banner read unless ctx has deadline
+	timeout time.Duration
+}
+
+func newTCPChecker(timeout time.Duration) *tcpChecker {
+	return &tcpChecker{
+		dialer:  net.Dialer{Timeout: timeout},
+		timeout: timeout,
+	}
+}
+
+func (c *tcpChecker) Check(ctx context.Context, site *sites.Site) (res sites.Result) {
+	res.CheckedAt = time.Now()
+	if site.Url.Port() == "" {
+		res.Error = "port is required"
+		return res
+	}
+
+	start := time.Now()
+	conn, err := c.dialer.DialContext(ctx, "tcp", site.Url.Host)
+	if err != nil {
+		res.Error = fmt.Sprintf("connect failed: %v", err)
+		return res
+	}
+	defer conn.Close()
+	// connect time includes name resolution
+	res.Timings.Connect = time.Since(start)
+
+	unbind, err := bindConn(ctx, conn, c.timeout)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer unbind()
+
+	if site.Body != "" {
+		if _, err := conn.Write([]byte(site.Body)); err != nil {
+			res.Error = fmt.Sprintf("failed to send: %v", err)
+			return res
+		}
+	}
+
+	if site.Assertions.ChecksBody() {
+		sent := time.Now()
+		firstByte, err := readBanner(conn, site.Assertions)
+		if !firstByte.IsZero() {
+			res.Timings.TTFB = firstByte.Sub(sent)
+			res.Timings.Transfer = time.Since(firstByte)
+		}
+		if err != nil {
+			res.Latency = time.Since(start)
+			res.Error = err.Error()
+			return res
+		}
+	}
+
+	res.Latency = time.Since(start)
+	res.Alive = true
+	return res
+}
+
+// readBanner reads conn until banner satisfies assertions, connection is closed or limit is reached.
+// Returns time the first byte is received at
+func readBanner(conn net.Conn, assertions *sites.Assertions) (time.Time, error) {
+	max := assertions.MaxBodySize
+	if max <= 0 {
+		max = defaultMaxBanner
+	}
+
+	var firstByte time.Time
+	banner := make([]byte, 0, 512)
+	chunk := make([]byte, 512)
+	for {
+		n, err := conn.Read(chunk)
+		if n > 0 && firstByte.IsZero() {
+			firstByte = time.Now()
+		}
+		banner = append(banner, chunk[:n]...)
+		if int64(len(banner)) > max {
+			return firstByte, fmt.Errorf("banner exceeds %d bytes", max)
+		}
+
+		checkErr := assertions.CheckBody(banner)
+		if checkErr == nil {
+			return firstByte, nil
+		}
+		if err != nil {
+			var netErr net.Error
+			if len(banner) == 0 || (errors.As(err, &netErr) && !netErr.Timeout()) {
+				return firstByte, fmt.Errorf("failed to read: %v", err)
+			}
+			return firstByte, checkErr
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package asker
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// serveTCP accepts connections and serves them with handle until test is finished
+func serveTCP(t *testing.T, handle func(conn net.Conn)) (addr string, teardown func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func TestTCPChecker(t *testing.T) {
+	addr, teardown := serveTCP(t, func(conn net.Conn) {
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		if line == "PING\r\n" {
+			_, _ = conn.Write([]byte("+PO"))
+			time.Sleep(10 * time.Millisecond)
+			_, _ = conn.Write([]byte("NG\r\n"))
+		}
+		// hold connection until client closes it
+		_, _ = conn.Read(make([]byte, 1))
+	})
+	defer teardown()
+
+	c := newTCPChecker(time.Second)
+
+	// connect only
+	res := c.Check(context.Background(), checkerSite(t, "tcp://"+addr, nil))
+	assert.True(t, res.Alive, res.Error)
+	assert.True(t, res.Timings.Connect > 0)
+	assert.True(t, res.Latency >= res.Timings.Connect)
+
+	// send and expect
+	site := checkerSite(t, "tcp://"+addr, &sites.Expectation{BodyContains: "+PONG"})
+	site.Body = "PING\r\n"
+	res = c.Check(context.Background(), site)
+	assert.True(t, res.Alive, res.Error)
+	assert.True(t, res.Timings.Transfer >= 10*time.Millisecond)
+
+	// banner never matches
+	site = checkerSite(t, "tcp://"+addr, &sites.Expectation{BodyContains: "+PONG"})
+	site.Body = "QUIT\r\n"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res = c.Check(ctx, site)
+	assert.False(t, res.Alive)
+	assert.Contains(t, res.Error, "failed to read")
+}
+
+func TestTCPChecker_Banner(t *testing.T) {
+	addr, teardown := serveTCP(t, func(conn net.Conn) {
+		_, _ = conn.Write([]byte("220 mx.example.com ESMTP ready\r\n"))
+	})
+	defer teardown()
+
+	c := newTCPChecker(time.Second)
+
+	res := c.Check(context.Background(), checkerSite(t, "tcp://"+addr, &sites.Expectation{BodyRegex: `^220 .*ESMTP`}))
+	assert.True(t, res.Alive, res.Error)
+	assert.True(t, res.Timings.TTFB > 0)
+
+	// server closes connection after banner
+	res = c.Check(context.Background(), checkerSite(t, "tcp://"+addr, &sites.Expectation{BodyContains: "Postfix"}))
+	assert.False(t, res.Alive)
+	assert.Equal(t, `body doesn't contain "Postfix"`, res.Error)
+
+	res = c.Check(context.Background(), checkerSite(t, "tcp://"+addr, &sites.Expectation{BodyContains: "Postfix", MaxBodySize: 10}))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "banner exceeds 10 bytes", res.Error)
+}
+
+func TestTCPChecker_Unreachable(t *testing.T) {
+	addr, teardown := serveTCP(t, func(conn net.Conn) {})
+	teardown()
+
+	c := newTCPChecker(time.Second)
+
+	res := c.Check(context.Background(), checkerSite(t, "tcp://"+addr, nil))
+	assert.False(t, res.Alive)
+	assert.Contains(t, res.Error, "connect failed")
+
+	res = c.Check(context.Background(), checkerSite(t, "tcp://127.0.0.1", nil))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "port is required", res.Error)
+}
@@ -0,0 +1,69 @@
+package asker
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mullakhmetov/status-board/internal/metrics"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// checkerSite returns site of rawurl checked against exp if set
+func checkerSite(t *testing.T, rawurl string, exp *sites.Expectation) *sites.Site {
+	url, err := url.Parse(rawurl)
+	assert.NoError(t, err)
+
+	site := &sites.Site{Name: url.Host, Url: url}
+	if exp != nil {
+		site.Assertions, err = sites.NewAssertions(*exp)
+		assert.NoError(t, err)
+	}
+	return site
+}
+
+func TestAsker_Checkers(t *testing.T) {
+	ftp, err := url.Parse("ftp://files.example.com")
+	assert.NoError(t, err)
+	custom, err := url.Parse("custom://example.com")
+	assert.NoError(t, err)
+
+	ss := []*sites.Site{
+		&sites.Site{Name: "ftp", Url: ftp},
+		&sites.Site{Name: "custom", Url: custom, Timeout: time.Minute},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	var deadline time.Time
+	checker := CheckerFunc(func(ctx context.Context, site *sites.Site) sites.Result {
+		deadline, _ = ctx.Deadline()
+		return sites.Result{CheckedAt: time.Now(), Alive: true, Latency: time.Millisecond}
+	})
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{
+		Timeout:  time.Second,
+		Checkers: map[string]Checker{"custom": checker},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.CheckAll(ctx)
+
+	r, err := a.Get(ctx, "custom")
+	assert.NoError(t, err)
+	assert.True(t, r.Alive)
+	assert.Equal(t, time.Millisecond, r.Latency)
+	// site timeout is applied to any checker
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	r, err = a.Get(ctx, "ftp")
+	assert.NoError(t, err)
+	assert.False(t, r.Alive)
+	assert.Equal(t, `unsupported scheme "ftp"`, r.Error)
+}
@@ -0,0 +1,108 @@
+package asker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// wsChecker performs upgrade handshake with ws:// and wss:// sites. Site body is sent as
+// text message once connected and replies are read until one satisfies site body assertions if any
+type wsChecker struct {
+	dialer websocket.Dialer
+	// timeout limits handshake and reply wait unless ctx has deadline
+	timeout time.Duration
+}
+
+func newWSChecker(timeout time.Duration) *wsChecker {
+	return &wsChecker{
+		dialer: websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: timeout,
+		},
+		timeout: timeout,
+	}
+}
+
+func (c *wsChecker) Check(ctx context.Context, site *sites.Site) (res sites.Result) {
+	res.CheckedAt = time.Now()
+
+	header := make(http.Header, len(site.Headers))
+	for k, v := range site.Headers {
+		header.Set(k, v)
+	}
+
+	start := time.Now()
+	conn, resp, err := c.dialer.DialContext(ctx, site.Url.String(), header)
+	if resp != nil {
+		res.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		res.Error = fmt.Sprintf("handshake failed: %v", err)
+		if err == websocket.ErrBadHandshake && resp != nil {
+			res.Error = fmt.Sprintf("handshake failed: unexpected status %d", resp.StatusCode)
+		}
+		return res
+	}
+	defer conn.Close()
+	res.Timings.Connect = time.Since(start)
+
+	unbind, err := bindConn(ctx, conn.UnderlyingConn(), c.timeout)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer unbind()
+
+	sent := time.Now()
+	if site.Body != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(site.Body)); err != nil {
+			res.Error = fmt.Sprintf("failed to send: %v", err)
+			return res
+		}
+	}
+
+	if site.Assertions.ChecksBody() {
+		if site.Assertions.MaxBodySize > 0 {
+			conn.SetReadLimit(site.Assertions.MaxBodySize)
+		}
+		err := readReply(conn, site.Assertions)
+		res.Timings.TTFB = time.Since(sent)
+		if err != nil {
+			res.Latency = time.Since(start)
+			res.Error = err.Error()
+			return res
+		}
+	}
+
+	// error is ignored as site may close connection first
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+
+	res.Latency = time.Since(start)
+	res.Alive = true
+	return res
+}
+
+// readReply reads messages until one of them satisfies assertions and returns the last failed assertion otherwise
+func readReply(conn *websocket.Conn, assertions *sites.Assertions) error {
+	var checkErr error
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if checkErr != nil {
+				return checkErr
+			}
+			return fmt.Errorf("failed to read: %v", err)
+		}
+
+		if checkErr = assertions.CheckBody(msg); checkErr == nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package asker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+func TestWSChecker(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// greeting goes before echo
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+			return
+		}
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, append([]byte("echo: "), msg...)); err != nil {
+				return
+			}
+		}
+	})
+	// broken upgrade path responds as plain http
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	c := newWSChecker(time.Second)
+
+	site := checkerSite(t, wsURL+"/echo", nil)
+	site.Headers = map[string]string{"Authorization": "token"}
+	res := c.Check(context.Background(), site)
+	assert.True(t, res.Alive, res.Error)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.True(t, res.Timings.Connect > 0)
+
+	site = checkerSite(t, wsURL+"/echo", &sites.Expectation{BodyContains: "echo: ping"})
+	site.Headers = map[string]string{"Authorization": "token"}
+	site.Body = "ping"
+	res = c.Check(context.Background(), site)
+	assert.True(t, res.Alive, res.Error)
+
+	// reply never matches
+	site = checkerSite(t, wsURL+"/echo", &sites.Expectation{BodyContains: "pong"})
+	site.Headers = map[string]string{"Authorization": "token"}
+	site.Body = "ping"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res = c.Check(ctx, site)
+	assert.False(t, res.Alive)
+	assert.Equal(t, `body doesn't contain "pong"`, res.Error)
+
+	res = c.Check(context.Background(), checkerSite(t, wsURL+"/echo", nil))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "handshake failed: unexpected status 401", res.Error)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = c.Check(context.Background(), checkerSite(t, wsURL+"/broken", nil))
+	assert.False(t, res.Alive)
+	assert.Equal(t, "handshake failed: unexpected status 200", res.Error)
+}
@@ -0,0 +1,135 @@
+package asker
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/metrics"
+)
+
+// LatencyStats summarizes resource latency of successful checks over a rolling window
+type LatencyStats struct {
+	Name    string
+	Window  time.Duration
+	Samples int
+
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+
+	Histogram []LatencyBucket
+}
+
+// LatencyBucket counts samples not greater than Le and greater than previous bucket bound.
+// The last bucket with zero Le counts samples exceeding all metrics.LatencyBuckets
+type LatencyBucket struct {
+	Le    time.Duration
+	Count int
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// latencyTracker keeps per resource latency samples within rolling window
+type latencyTracker struct {
+	lock    sync.Mutex
+	window  time.Duration
+	samples map[string][]latencySample
+}
+
+func newLatencyTracker(window time.Duration) *latencyTracker {
+	return &latencyTracker{
+		window:  window,
+		samples: make(map[string][]latencySample),
+	}
+}
+
+func (t *latencyTracker) add(name string, at time.Time, latency time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	samples := append(t.samples[name], latencySample{at, latency})
+	t.samples[name] = expire(samples, at.Add(-t.window))
+}
+
+// sync drops samples of resources not listed
+func (t *latencyTracker) sync(names []string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	actual := make(map[string]bool, len(names))
+	for _, name := range names {
+		actual[name] = true
+	}
+	for name := range t.samples {
+		if !actual[name] {
+			delete(t.samples, name)
+		}
+	}
+}
+
+func (t *latencyTracker) stats(name string, now time.Time) LatencyStats {
+	t.lock.Lock()
+	samples := expire(t.samples[name], now.Add(-t.window))
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		latencies = append(latencies, s.latency)
+	}
+	t.lock.Unlock()
+
+	res := LatencyStats{Name: name, Window: t.window, Samples: len(latencies)}
+	res.Histogram = make([]LatencyBucket, len(metrics.LatencyBuckets)+1)
+	for i, le := range metrics.LatencyBuckets {
+		res.Histogram[i].Le = le
+	}
+	if len(latencies) == 0 {
+		return res
+	}
+
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+		i := sort.Search(len(metrics.LatencyBuckets), func(i int) bool {
+			return l <= metrics.LatencyBuckets[i]
+		})
+		res.Histogram[i].Count++
+	}
+
+	res.Min = latencies[0]
+	res.Max = latencies[len(latencies)-1]
+	res.Mean = sum / time.Duration(len(latencies))
+	res.P50 = percentile(latencies, 50)
+	res.P90 = percentile(latencies, 90)
+	res.P99 = percentile(latencies, 99)
+
+	return res
+}
+
+// expire drops samples taken before since, samples are ordered by time
+func expire(samples []latencySample, since time.Time) []latencySample {
+	i := 0
+	for i < len(samples) && samples[i].at.Before(since) {
+		i++
+	}
+	return samples[i:]
+}
+
+// percentile returns nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,41 @@
+package asker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := newLatencyTracker(time.Minute)
+	now := time.Now()
+
+	// expired sample
+	tracker.add("google.com", now.Add(-2*time.Minute), time.Hour)
+	for i := 1; i <= 100; i++ {
+		tracker.add("google.com", now.Add(-time.Minute+time.Duration(i)*time.Millisecond), time.Duration(i)*time.Millisecond)
+	}
+
+	stats := tracker.stats("google.com", now)
+	assert.Equal(t, 100, stats.Samples)
+	assert.Equal(t, time.Minute, stats.Window)
+	assert.Equal(t, time.Millisecond, stats.Min)
+	assert.Equal(t, 100*time.Millisecond, stats.Max)
+	assert.Equal(t, 50500*time.Microsecond, stats.Mean)
+	assert.Equal(t, 50*time.Millisecond, stats.P50)
+	assert.Equal(t, 90*time.Millisecond, stats.P90)
+	assert.Equal(t, 99*time.Millisecond, stats.P99)
+
+	assert.Equal(t, len(metrics.LatencyBuckets)+1, len(stats.Histogram))
+	assert.Equal(t, LatencyBucket{Le: 10 * time.Millisecond, Count: 10}, stats.Histogram[0])
+	assert.Equal(t, LatencyBucket{Le: 25 * time.Millisecond, Count: 15}, stats.Histogram[1])
+	assert.Equal(t, LatencyBucket{Le: 100 * time.Millisecond, Count: 50}, stats.Histogram[3])
+	assert.Equal(t, 0, stats.Histogram[len(metrics.LatencyBuckets)].Count)
+
+	tracker.sync([]string{"vk.com"})
+	stats = tracker.stats("google.com", now)
+	assert.Equal(t, 0, stats.Samples)
+	assert.Equal(t, time.Duration(0), stats.P99)
+}
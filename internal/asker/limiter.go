@@ -0,0 +1,143 @@
+package asker
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter bounds number of concurrent checks globally and per host. Zero limit means unlimited
+type limiter struct {
+	global chan struct{}
+
+	lock      sync.Mutex
+	hostLimit int
+	hosts     map[string]*hostSemaphore
+}
+
+// hostSemaphore is dropped once no check holds or waits for it, so hosts of removed sites aren't kept
+type hostSemaphore struct {
+	sem chan struct{}
+	// users is a number of checks holding or waiting for slot
+	users int
+}
+
+func newLimiter(limit, hostLimit int) *limiter {
+	l := &limiter{
+		hostLimit: hostLimit,
+		hosts:     make(map[string]*hostSemaphore),
+	}
+	if limit > 0 {
+		l.global = make(chan struct{}, limit)
+	}
+	return l
+}
+
+// acquire blocks until both host and global slots are free. Host slot is taken first
+// so checks queued for a busy host don't hold global slots
+func (l *limiter) acquire(ctx context.Context, host string) (release func(), err error) {
+	hostSem := l.joinHost(host)
+
+	if err := take(ctx, hostSem); err != nil {
+		l.leaveHost(host)
+		return nil, err
+	}
+	if err := take(ctx, l.global); err != nil {
+		put(hostSem)
+		l.leaveHost(host)
+		return nil, err
+	}
+
+	return func() {
+		put(l.global)
+		put(hostSem)
+		l.leaveHost(host)
+	}, nil
+}
+
+// tryAcquire takes host and global slots if both are free right away
+func (l *limiter) tryAcquire(host string) (release func(), ok bool) {
+	hostSem := l.joinHost(host)
+
+	if !tryTake(hostSem) {
+		l.leaveHost(host)
+		return nil, false
+	}
+	if !tryTake(l.global) {
+		put(hostSem)
+		l.leaveHost(host)
+		return nil, false
+	}
+
+	return func() {
+		put(l.global)
+		put(hostSem)
+		l.leaveHost(host)
+	}, true
+}
+
+// joinHost returns host semaphore registering caller as it's user
+func (l *limiter) joinHost(host string) chan struct{} {
+	if l.hostLimit <= 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostSemaphore{sem: make(chan struct{}, l.hostLimit)}
+		l.hosts[host] = h
+	}
+	h.users++
+	return h.sem
+}
+
+// leaveHost drops host semaphore once it's last user leaves
+func (l *limiter) leaveHost(host string) {
+	if l.hostLimit <= 0 {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if h, ok := l.hosts[host]; ok {
+		h.users--
+		if h.users == 0 {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// take acquires semaphore slot, nil semaphore is unlimited
+func take(ctx context.Context, sem chan struct{}) error {
+	if sem == nil {
+		return nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryTake acquires semaphore slot if it's free, nil semaphore is unlimited
+func tryTake(sem chan struct{}) bool {
+	if sem == nil {
+		return true
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func put(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+}
@@ -0,0 +1,122 @@
+package asker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(3, 2)
+
+	var active, maxActive int32
+	hostActive := map[string]*int32{"a": new(int32), "b": new(int32)}
+	var hostMaxExceeded int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		host := "a"
+		if i%2 == 0 {
+			host = "b"
+		}
+
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+
+			release, err := l.acquire(context.Background(), host)
+			assert.NoError(t, err)
+			defer release()
+
+			n := atomic.AddInt32(&active, 1)
+			for {
+				max := atomic.LoadInt32(&maxActive)
+				if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+					break
+				}
+			}
+			if atomic.AddInt32(hostActive[host], 1) > 2 {
+				atomic.StoreInt32(&hostMaxExceeded, 1)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+
+			atomic.AddInt32(hostActive[host], -1)
+			atomic.AddInt32(&active, -1)
+		}(host)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), maxActive)
+	assert.Equal(t, int32(0), hostMaxExceeded)
+	// idle hosts are dropped
+	assert.Empty(t, l.hosts)
+}
+
+func TestLimiter_Cancel(t *testing.T) {
+	l := newLimiter(1, 0)
+
+	release, err := l.acquire(context.Background(), "a")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "b")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	release()
+	release, err = l.acquire(context.Background(), "b")
+	assert.NoError(t, err)
+	release()
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l := newLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		_, err := l.acquire(context.Background(), "a")
+		assert.NoError(t, err)
+	}
+}
+
+func TestLimiter_HostCancel(t *testing.T) {
+	l := newLimiter(0, 1)
+
+	release, err := l.acquire(context.Background(), "a")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx, "a")
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(l.hosts))
+
+	release()
+	assert.Empty(t, l.hosts)
+}
+
+func TestLimiter_TryAcquire(t *testing.T) {
+	l := newLimiter(2, 1)
+
+	release, ok := l.tryAcquire("a")
+	assert.True(t, ok)
+	// host slot is busy
+	_, ok = l.tryAcquire("a")
+	assert.False(t, ok)
+
+	releaseB, ok := l.tryAcquire("b")
+	assert.True(t, ok)
+	// global slots are busy
+	_, ok = l.tryAcquire("c")
+	assert.False(t, ok)
+
+	release()
+	releaseB()
+	release, ok = l.tryAcquire("a")
+	assert.True(t, ok)
+	release()
+	assert.Empty(t, l.hosts)
+}
@@ -0,0 +1,197 @@
+package asker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// List sort orders
+const (
+	SortName       = "name"
+	SortLatency    = "latency"
+	SortLastChange = "last-change"
+)
+
+// ListQuery filters, orders and paginates resources list
+type ListQuery struct {
+	// Alive filters resources by availability if set
+	Alive *bool
+	// Name is a shell pattern resource name must match, e.g. "*.com"
+	Name string
+	// Tags resource must have all of
+	Tags []string
+	// Sort is one of Sort* orders, by name if empty
+	Sort string
+	Desc bool
+	// Limit is a maximum number of resources per page
+	Limit int
+	// Cursor is a NextCursor of the previous page, the first page is returned if empty
+	Cursor string
+}
+
+// ListPage is a page of resources list
+type ListPage struct {
+	// Items don't include uptime, it's reported for single resource only
+	Items []Response
+	// Total is a number of resources matching query filters
+	Total int
+	// NextCursor continues list from the end of the page, empty for the last page
+	NextCursor string `json:",omitempty"`
+}
+
+// ValidationError is returned on malformed list query
+type ValidationError struct {
+	err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.err.Error()
+}
+
+// cursor is a position in ordered list, it keeps sort key of the last returned resource
+// so that pages aren't shifted by resources added, removed or reordered between requests
+type cursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	Key  int64  `json:"k,omitempty"`
+	Name string `json:"n"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (c cursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("Invalid cursor %s", s)
+	}
+	return c, nil
+}
+
+// compare orders cursors by sort key and name within key
+func (c cursor) compare(other cursor) int {
+	res := 0
+	switch {
+	case c.Key < other.Key:
+		res = -1
+	case c.Key > other.Key:
+		res = 1
+	case c.Name < other.Name:
+		res = -1
+	case c.Name > other.Name:
+		res = 1
+	}
+	if c.Desc {
+		return -res
+	}
+	return res
+}
+
+// listEntry is a resource along with it's position in ordered list
+type listEntry struct {
+	site *sites.Site
+	cursor
+}
+
+func sortKey(sortBy string, status sites.Status) int64 {
+	switch sortBy {
+	case SortLatency:
+		return int64(status.Latency)
+	case SortLastChange:
+		if status.ChangedAt.IsZero() {
+			return 0
+		}
+		return status.ChangedAt.UnixNano()
+	default:
+		return 0
+	}
+}
+
+// List returns page of resources matching query
+func (a *httpAsker) List(ctx context.Context, q ListQuery) (page ListPage, err error) {
+	if q.Sort == "" {
+		q.Sort = SortName
+	}
+	switch q.Sort {
+	case SortName, SortLatency, SortLastChange:
+	default:
+		return page, &ValidationError{fmt.Errorf("Invalid sort %s", q.Sort)}
+	}
+	if _, err := path.Match(q.Name, ""); err != nil {
+		return page, &ValidationError{fmt.Errorf("Invalid name pattern %s", q.Name)}
+	}
+
+	var after *cursor
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return page, &ValidationError{err}
+		}
+		if c.Sort != q.Sort || c.Desc != q.Desc {
+			return page, &ValidationError{fmt.Errorf("Cursor doesn't match sort %s", q.Sort)}
+		}
+		after = &c
+	}
+
+	entries := make([]listEntry, 0)
+	for _, site := range a.SitesService.GetAll() {
+		status := site.Status()
+		if !matches(site, status, q) {
+			continue
+		}
+		entries = append(entries, listEntry{
+			site:   site,
+			cursor: cursor{Sort: q.Sort, Desc: q.Desc, Key: sortKey(q.Sort, status), Name: site.Name},
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].compare(entries[j].cursor) < 0
+	})
+	page.Total = len(entries)
+
+	if after != nil {
+		start := sort.Search(len(entries), func(i int) bool {
+			return entries[i].compare(*after) > 0
+		})
+		entries = entries[start:]
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		page.NextCursor = entries[q.Limit-1].cursor.encode()
+		entries = entries[:q.Limit]
+	}
+
+	page.Items = make([]Response, 0, len(entries))
+	for _, e := range entries {
+		page.Items = append(page.Items, a.response(e.site))
+	}
+
+	return page, nil
+}
+
+func matches(site *sites.Site, status sites.Status, q ListQuery) bool {
+	if q.Alive != nil && status.Alive != *q.Alive {
+		return false
+	}
+	if q.Name != "" {
+		if ok, _ := path.Match(q.Name, site.Name); !ok {
+			return false
+		}
+	}
+	for _, tag := range q.Tags {
+		if !site.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package asker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/history"
+	"github.com/mullakhmetov/status-board/internal/metrics"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+func listAsker(t *testing.T) *httpAsker {
+	now := time.Now()
+	site := func(name string, alive bool, latency time.Duration, changedAgo time.Duration, tags ...string) *sites.Site {
+		s := &sites.Site{Name: name, Tags: tags}
+		s.Record(sites.Result{CheckedAt: now.Add(-changedAgo), Alive: alive, Latency: latency}, sites.Hysteresis{})
+		return s
+	}
+	ss := []*sites.Site{
+		site("vk.com", true, 300*time.Millisecond, time.Hour, "social"),
+		site("google.com", true, 100*time.Millisecond, time.Minute, "search", "prod"),
+		site("yandex.ru", false, 0, 2*time.Hour, "search"),
+		site("github.com", true, 200*time.Millisecond, 3*time.Hour, "prod"),
+		{Name: "unknown.org"},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedMetrics := metrics.Registry{
+		InitCounterFunc: func(name string) metrics.Counter { return new(metrics.MockedCounter) },
+		Counters:        make(map[string]metrics.Counter),
+	}
+
+	h := history.NewMemoryHistory(time.Hour)
+	err := h.Append(history.Record{Site: "google.com", Result: sites.Result{CheckedAt: now, Alive: true}})
+	assert.NoError(t, err)
+
+	return NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second, History: h}).(*httpAsker)
+}
+
+func names(page ListPage) []string {
+	res := make([]string, 0, len(page.Items))
+	for _, r := range page.Items {
+		res = append(res, r.Name)
+	}
+	return res
+}
+
+func TestAsker_List(t *testing.T) {
+	a := listAsker(t)
+	ctx := context.Background()
+	alive, dead := true, false
+
+	page, err := a.List(ctx, ListQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"github.com", "google.com", "unknown.org", "vk.com", "yandex.ru"}, names(page))
+	assert.Equal(t, 5, page.Total)
+	assert.Empty(t, page.NextCursor)
+	// uptime is calculated for single site response only
+	for _, r := range page.Items {
+		assert.Nil(t, r.Uptime)
+	}
+	r, err := a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.NotNil(t, r.Uptime)
+
+	page, err = a.List(ctx, ListQuery{Alive: &alive, Sort: SortLatency})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"google.com", "github.com", "vk.com"}, names(page))
+	assert.Equal(t, 100*time.Millisecond, page.Items[0].Latency)
+
+	page, err = a.List(ctx, ListQuery{Alive: &dead})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unknown.org", "yandex.ru"}, names(page))
+
+	page, err = a.List(ctx, ListQuery{Name: "*.com", Tags: []string{"prod"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"github.com", "google.com"}, names(page))
+
+	page, err = a.List(ctx, ListQuery{Tags: []string{"search", "prod"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"google.com"}, names(page))
+
+	// the latest changes first, never checked site last
+	page, err = a.List(ctx, ListQuery{Sort: SortLastChange, Desc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"google.com", "vk.com", "yandex.ru", "github.com", "unknown.org"}, names(page))
+}
+
+func TestAsker_List_Pagination(t *testing.T) {
+	a := listAsker(t)
+	ctx := context.Background()
+
+	var all []string
+	q := ListQuery{Sort: SortLatency, Desc: true, Limit: 2}
+	for i := 0; i < 3; i++ {
+		page, err := a.List(ctx, q)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		all = append(all, names(page)...)
+		if i < 2 {
+			assert.NotEmpty(t, page.NextCursor)
+		} else {
+			assert.Empty(t, page.NextCursor)
+		}
+		q.Cursor = page.NextCursor
+	}
+	// reversed order reverses names within the same latency too
+	assert.Equal(t, []string{"vk.com", "github.com", "google.com", "yandex.ru", "unknown.org"}, all)
+
+	// page continues after the last seen site even if it's gone
+	c := cursor{Sort: SortName, Name: "google.com"}
+	page, err := a.List(ctx, ListQuery{Cursor: c.encode()})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unknown.org", "vk.com", "yandex.ru"}, names(page))
+	c = cursor{Sort: SortName, Name: "gone.com"}
+	page, err = a.List(ctx, ListQuery{Cursor: c.encode()})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"google.com", "unknown.org", "vk.com", "yandex.ru"}, names(page))
+}
+
+func TestAsker_List_Invalid(t *testing.T) {
+	a := listAsker(t)
+	ctx := context.Background()
+
+	for _, q := range []ListQuery{
+		{Sort: "uptime"},
+		{Name: "[a-"},
+		{Cursor: "garbage!"},
+		{Sort: SortLatency, Cursor: cursor{Sort: SortName, Name: "vk.com"}.encode()},
+	} {
+		_, err := a.List(ctx, q)
+		assert.IsType(t, &ValidationError{}, err, q)
+	}
+}
@@ -0,0 +1,191 @@
+package asker
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// defaultInterval is used when neither site interval nor checks rate is set
+const defaultInterval = time.Minute
+
+// resyncPeriod is how often scheduler looks for added and removed sites
+const resyncPeriod = time.Second
+
+// clock abstracts time so scheduler can be tested deterministically
+type clock interface {
+	Now() time.Time
+	// At returns channel receiving current time once t is reached
+	At(t time.Time) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) At(t time.Time) <-chan time.Time { return time.After(time.Until(t)) }
+
+// scheduler checks every site on its own interval. Slow checks don't delay others,
+// a site is never checked again until its previous check is finished.
+// Due site is left waiting in schedule until acquire gives it a slot, so no goroutine is parked for it
+type scheduler struct {
+	clock clock
+	// rate is default interval for sites without one
+	rate time.Duration
+	// jitter is a fraction of interval randomly added or subtracted, e.g. 0.1 is ±10%
+	jitter float64
+	// spread starts checks of sites known at start evenly across their intervals
+	spread bool
+	random func() float64
+	sites  func() []*sites.Site
+	// check is given slot acquired for site, nil if acquire isn't set
+	check func(ctx context.Context, site *sites.Site, release func())
+	// acquire takes check slot if it's free right away, optional
+	acquire func(site *sites.Site) (release func(), ok bool)
+	// changed is called with current sites once any site is added or removed, optional
+	changed func(ss []*sites.Site)
+
+	entries map[*sites.Site]*schedule
+}
+
+type schedule struct {
+	next    time.Time
+	running bool
+}
+
+func newScheduler(c clock, rate time.Duration, jitter float64, ss func() []*sites.Site, check func(context.Context, *sites.Site, func())) *scheduler {
+	return &scheduler{
+		clock:   c,
+		rate:    rate,
+		jitter:  jitter,
+		random:  rand.Float64,
+		sites:   ss,
+		check:   check,
+		entries: make(map[*sites.Site]*schedule),
+	}
+}
+
+// run schedules checks until ctx is done. Sites known at start are considered just checked
+// unless spread is set, sites added later are checked right away
+func (s *scheduler) run(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	done := make(chan *sites.Site)
+	now := s.clock.Now()
+	s.sync(now, false)
+	synced := now
+
+	for {
+		now = s.clock.Now()
+		if !now.Before(synced.Add(resyncPeriod)) {
+			s.sync(now, true)
+			synced = now
+		}
+
+		wake := synced.Add(resyncPeriod)
+		for site, e := range s.entries {
+			if e.running {
+				continue
+			}
+			if !e.next.After(now) {
+				var release func()
+				if s.acquire != nil {
+					var ok bool
+					if release, ok = s.acquire(site); !ok {
+						// retried once a check is done or on resync
+						continue
+					}
+				}
+				e.running = true
+				e.next = now.Add(s.interval(site))
+
+				wg.Add(1)
+				go func(site *sites.Site, release func()) {
+					defer wg.Done()
+					s.check(ctx, site, release)
+					select {
+					case done <- site:
+					case <-ctx.Done():
+					}
+				}(site, release)
+			}
+			if e.next.Before(wake) {
+				wake = e.next
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case site := <-done:
+			if e, ok := s.entries[site]; ok {
+				e.running = false
+			}
+		case <-s.clock.At(wake):
+		}
+	}
+}
+
+// sync adds schedules of new sites and drops removed ones
+func (s *scheduler) sync(now time.Time, immediate bool) {
+	ss := s.sites()
+
+	changed := false
+	present := make(map[*sites.Site]bool, len(ss))
+	for i, site := range ss {
+		present[site] = true
+		if _, ok := s.entries[site]; ok {
+			continue
+		}
+		changed = true
+		next := now
+		switch {
+		case immediate:
+		case s.spread:
+			next = now.Add(s.interval(site) * time.Duration(i) / time.Duration(len(ss)))
+		default:
+			next = now.Add(s.interval(site))
+		}
+		s.entries[site] = &schedule{next: next}
+	}
+
+	for site := range s.entries {
+		if !present[site] {
+			delete(s.entries, site)
+			changed = true
+		}
+	}
+
+	if changed && s.changed != nil {
+		s.changed(ss)
+	}
+}
+
+// interval returns site check interval with jitter applied
+func (s *scheduler) interval(site *sites.Site) time.Duration {
+	d := site.Interval
+	if d <= 0 {
+		d = s.rate
+	}
+	if d <= 0 {
+		d = defaultInterval
+	}
+	if s.jitter > 0 {
+		jittered := d + time.Duration(float64(d)*s.jitter*(2*s.random()-1))
+		// too large jitter must not turn into a tight loop of checks
+		if jittered < d/2 {
+			jittered = d / 2
+		}
+		d = jittered
+	}
+
+	return d
+}
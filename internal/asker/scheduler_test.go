@@ -0,0 +1,316 @@
+package asker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// fakeClock is moved forward by Advance only
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) At(t time.Time) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if !t.After(c.now) {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, fakeTimer{at: t, ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = pending
+}
+
+type schedulerTest struct {
+	t      *testing.T
+	clock  *fakeClock
+	calls  chan string
+	synced chan struct{}
+
+	lock  sync.Mutex
+	sites []*sites.Site
+}
+
+func newSchedulerTest(t *testing.T, ss ...*sites.Site) *schedulerTest {
+	return &schedulerTest{t: t, clock: newFakeClock(), calls: make(chan string, 100), synced: make(chan struct{}, 1), sites: ss}
+}
+
+func (st *schedulerTest) getAll() []*sites.Site {
+	select {
+	case st.synced <- struct{}{}:
+	default:
+	}
+
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	return st.sites
+}
+
+func (st *schedulerTest) setSites(ss ...*sites.Site) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.sites = ss
+}
+
+func (st *schedulerTest) check(ctx context.Context, site *sites.Site, release func()) {
+	if release != nil {
+		defer release()
+	}
+	st.calls <- site.Name
+}
+
+// expect advances clock and asserts exactly expected sites are checked
+func (st *schedulerTest) expect(d time.Duration, expected ...string) {
+	st.clock.Advance(d)
+
+	called := []string{}
+	for range expected {
+		select {
+		case name := <-st.calls:
+			called = append(called, name)
+		case <-time.After(time.Second):
+		}
+	}
+	select {
+	case name := <-st.calls:
+		called = append(called, name)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	sort.Strings(called)
+	sort.Strings(expected)
+	if expected == nil {
+		expected = []string{}
+	}
+	assert.Equal(st.t, expected, called, "after %s", d)
+}
+
+func (st *schedulerTest) run(s *scheduler) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		s.run(ctx)
+		close(stopped)
+	}()
+	// wait for initial sync so clock isn't advanced before scheduler starts
+	<-st.synced
+
+	return func() {
+		cancel()
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			st.t.Error("scheduler is not stopped")
+		}
+	}
+}
+
+func TestScheduler_Intervals(t *testing.T) {
+	fast := &sites.Site{Name: "fast", Interval: 10 * time.Second}
+	slow := &sites.Site{Name: "slow"}
+	st := newSchedulerTest(t, fast, slow)
+
+	s := newScheduler(st.clock, 30*time.Second, 0, st.getAll, st.check)
+	stop := st.run(s)
+	defer stop()
+
+	st.expect(5 * time.Second)
+	st.expect(5*time.Second, "fast")
+	st.expect(10*time.Second, "fast")
+	st.expect(10*time.Second, "fast", "slow")
+	st.expect(9 * time.Second)
+	st.expect(time.Second, "fast")
+}
+
+func TestScheduler_SlowCheck(t *testing.T) {
+	hang := &sites.Site{Name: "hang", Interval: 10 * time.Second}
+	fast := &sites.Site{Name: "fast", Interval: 10 * time.Second}
+	st := newSchedulerTest(t, hang, fast)
+
+	release := make(chan struct{})
+	check := func(ctx context.Context, site *sites.Site, _ func()) {
+		st.check(ctx, site, nil)
+		if site == hang {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	s := newScheduler(st.clock, time.Minute, 0, st.getAll, check)
+	stop := st.run(s)
+	defer stop()
+
+	st.expect(10*time.Second, "hang", "fast")
+	// hanging check doesn't delay others and isn't started twice
+	st.expect(10*time.Second, "fast")
+	st.expect(10*time.Second, "fast")
+
+	// overdue site is checked as soon as previous check is finished
+	release <- struct{}{}
+	st.expect(0, "hang")
+	close(release)
+	st.expect(10*time.Second, "hang", "fast")
+}
+
+func TestScheduler_Jitter(t *testing.T) {
+	site := &sites.Site{Name: "google.com", Interval: 10 * time.Second}
+	st := newSchedulerTest(t, site)
+
+	s := newScheduler(st.clock, time.Minute, 0.5, st.getAll, st.check)
+	s.random = func() float64 { return 1 }
+	stop := st.run(s)
+	defer stop()
+
+	st.expect(10 * time.Second)
+	st.expect(5*time.Second, "google.com")
+	st.expect(14 * time.Second)
+	st.expect(time.Second, "google.com")
+}
+
+func TestScheduler_Spread(t *testing.T) {
+	st := newSchedulerTest(t, &sites.Site{Name: "a"}, &sites.Site{Name: "b"}, &sites.Site{Name: "c"}, &sites.Site{Name: "d"})
+
+	s := newScheduler(st.clock, 40*time.Second, 0, st.getAll, st.check)
+	s.spread = true
+	stop := st.run(s)
+	defer stop()
+
+	// the first checks are spread across interval
+	st.expect(0, "a")
+	st.expect(10*time.Second, "b")
+	st.expect(10*time.Second, "c")
+	st.expect(10*time.Second, "d")
+	st.expect(10*time.Second, "a")
+}
+
+func TestScheduler_JitterBounds(t *testing.T) {
+	site := &sites.Site{Name: "google.com", Interval: 10 * time.Second}
+	s := newScheduler(realClock{}, time.Minute, 0.1, nil, nil)
+
+	for i := 0; i < 1000; i++ {
+		d := s.interval(site)
+		assert.True(t, d >= 9*time.Second && d <= 11*time.Second, d.String())
+	}
+
+	// interval is never shorter than a half
+	s.jitter = 2
+	s.random = func() float64 { return 0 }
+	assert.Equal(t, 5*time.Second, s.interval(site))
+
+	s.jitter = 0
+	assert.Equal(t, 10*time.Second, s.interval(site))
+	assert.Equal(t, time.Minute, s.interval(&sites.Site{}))
+	s.rate = 0
+	assert.Equal(t, defaultInterval, s.interval(&sites.Site{}))
+}
+
+func TestScheduler_Acquire(t *testing.T) {
+	st := newSchedulerTest(t, &sites.Site{Name: "a", Interval: 10 * time.Second}, &sites.Site{Name: "b", Interval: 10 * time.Second})
+
+	slots := make(chan struct{}, 1)
+	proceed := make(chan struct{})
+	s := newScheduler(st.clock, time.Minute, 0, st.getAll, func(ctx context.Context, site *sites.Site, release func()) {
+		st.calls <- site.Name
+		select {
+		case <-proceed:
+		case <-ctx.Done():
+		}
+		release()
+	})
+	s.acquire = func(site *sites.Site) (func(), bool) {
+		select {
+		case slots <- struct{}{}:
+			return func() { <-slots }, true
+		default:
+			return nil, false
+		}
+	}
+	stop := st.run(s)
+	defer stop()
+
+	// site without free slot waits in schedule instead of check goroutine
+	st.clock.Advance(10 * time.Second)
+	first := <-st.calls
+	select {
+	case name := <-st.calls:
+		t.Fatalf("%s is checked without slot", name)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	proceed <- struct{}{}
+	second := <-st.calls
+	proceed <- struct{}{}
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{first, second})
+}
+
+func TestScheduler_SitesChanged(t *testing.T) {
+	google := &sites.Site{Name: "google.com", Interval: 10 * time.Second}
+	vk := &sites.Site{Name: "vk.com", Interval: 10 * time.Second}
+	st := newSchedulerTest(t, google)
+
+	var lock sync.Mutex
+	var changes [][]*sites.Site
+	s := newScheduler(st.clock, time.Minute, 0, st.getAll, st.check)
+	s.changed = func(ss []*sites.Site) {
+		lock.Lock()
+		defer lock.Unlock()
+		changes = append(changes, ss)
+	}
+	stop := st.run(s)
+
+	// added site is checked right away on next sync, removed one is not checked anymore
+	st.setSites(vk)
+	st.expect(resyncPeriod, "vk.com")
+	st.expect(10*time.Second - resyncPeriod)
+	st.expect(resyncPeriod, "vk.com")
+	stop()
+
+	// unchanged sites aren't reported on every sync
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, [][]*sites.Site{{google}, {vk}}, changes)
+}
@@ -2,38 +2,118 @@ package asker
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/mullakhmetov/status-board/internal/history"
 	"github.com/mullakhmetov/status-board/internal/metrics"
 	"github.com/mullakhmetov/status-board/internal/sites"
 )
 
+// Opts configures asker
+type Opts struct {
+	// Timeout limits connection establishment, see Site.Timeout for whole check timeout
+	Timeout time.Duration
+	// Rate is a period of checks
+	Rate time.Duration
+	// History stores every check result if set
+	History history.Service
+	// UptimeCache serves uptime of History, it's created with defaultUptimeTTL if not set
+	UptimeCache *history.UptimeCache
+	// LatencyWindow is a period latency statistics is calculated over, an hour by default
+	LatencyWindow time.Duration
+	// Listeners are notified on every check result
+	Listeners []Listener
+	// Concurrency limits number of simultaneous checks, unlimited if zero
+	Concurrency int
+	// HostConcurrency limits number of simultaneous checks per host, unlimited if zero
+	HostConcurrency int
+	// Spread distributes CheckAll checks start evenly across Rate instead of starting all of them at once
+	Spread bool
+	// Jitter is a fraction of check interval randomly added or subtracted, e.g. 0.1 is ±10%
+	Jitter float64
+	// Retries is a number of extra requests made within a check before it's considered failed
+	Retries int
+	// RetryBackoff is a delay before the first retry, doubled for every next one
+	RetryBackoff time.Duration
+	// Hysteresis is a number of consecutive failures and successes required to change site state
+	Hysteresis sites.Hysteresis
+	// CertWarning marks available sites with certificate expiring within as degraded, disabled if zero
+	CertWarning time.Duration
+	// Checkers add or override checkers by url scheme, http(s), tcp, dns, grpc(s) and ws(s) are supported by default
+	Checkers map[string]Checker
+}
+
+// defaultUptimeTTL is a period uptime is cached for
+const defaultUptimeTTL = time.Minute
+
 // NewHttpAsker returns asker for http services
-func NewHttpAsker(s sites.Service, metricsRegistry *metrics.Registry, timeout time.Duration, rate time.Duration) Service {
+func NewHttpAsker(s sites.Service, metricsRegistry *metrics.Registry, opts Opts) Service {
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
-			Timeout: timeout,
+			Timeout: opts.Timeout,
 		}).DialContext,
+		// every check opens a new connection so that dns, connect and tls timings are measured each time
+		DisableKeepAlives: true,
 	}
 	client := http.Client{Transport: transport}
 
-	// init metric counters
-	for _, site := range s.GetAll() {
-		metricsRegistry.AddCounter(site.Name)
+	if opts.LatencyWindow == 0 {
+		opts.LatencyWindow = time.Hour
+	}
+	if opts.History != nil && opts.UptimeCache == nil {
+		opts.UptimeCache = history.NewUptimeCache(opts.History, defaultUptimeTTL)
 	}
 
-	return &httpAsker{
+	a := &httpAsker{
 		SitesService:    s,
 		MetricsRegistry: metricsRegistry,
 		httpClient:      client,
-		rate:            rate,
+		rate:            opts.Rate,
+		history:         opts.History,
+		uptime:          opts.UptimeCache,
+		latencies:       newLatencyTracker(opts.LatencyWindow),
+		listeners:       opts.Listeners,
+		limiter:         newLimiter(opts.Concurrency, opts.HostConcurrency),
+		spread:          opts.Spread,
+		retries:         opts.Retries,
+		retryBackoff:    opts.RetryBackoff,
+		hysteresis:      opts.Hysteresis,
+		certWarning:     opts.CertWarning,
+	}
+	a.scheduler = newScheduler(realClock{}, opts.Rate, opts.Jitter, s.GetAll, a.checkSite)
+	a.scheduler.spread = opts.Spread
+	a.scheduler.changed = a.syncSites
+	a.scheduler.acquire = func(site *sites.Site) (func(), bool) {
+		return a.limiter.tryAcquire(site.Url.Hostname())
+	}
+	a.checkers = map[string]Checker{
+		"http":  CheckerFunc(a.ask),
+		"https": CheckerFunc(a.ask),
+		"tcp":   newTCPChecker(opts.Timeout),
+		"dns":   newDNSChecker(opts.Timeout),
+		"grpc":  newGRPCChecker(opts.Timeout),
+		"grpcs": newGRPCChecker(opts.Timeout),
+		"ws":    newWSChecker(opts.Timeout),
+		"wss":   newWSChecker(opts.Timeout),
+	}
+	for scheme, checker := range opts.Checkers {
+		a.checkers[scheme] = checker
 	}
+	// init metric counters
+	a.syncSites(s.GetAll())
+
+	return a
 }
 
 type httpAsker struct {
@@ -41,54 +121,83 @@ type httpAsker struct {
 	MetricsRegistry *metrics.Registry
 	httpClient      http.Client
 	rate            time.Duration
+	history         history.Service
+	uptime          *history.UptimeCache
+	latencies       *latencyTracker
+	listeners       []Listener
+	limiter         *limiter
+	spread          bool
+	scheduler       *scheduler
+	retries         int
+	retryBackoff    time.Duration
+	hysteresis      sites.Hysteresis
+	certWarning     time.Duration
+	checkers        map[string]Checker
 }
 
-// Run starts infitite loop that periodically checks all resources availability
+// Run checks all resources availability and starts scheduler that rechecks every resource on its own interval.
+// Spread startup checks take the whole interval, so they are left to scheduler and Run doesn't wait for them
 func (a *httpAsker) Run(ctx context.Context) {
-	a.CheckAll(ctx)
-
-	ticker := time.NewTicker(a.rate)
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case _ = <-ticker.C:
-				a.CheckAll(ctx)
-			}
-		}
-	}()
+	if !a.spread {
+		a.CheckAll(ctx)
+	}
+
+	go a.scheduler.run(ctx)
 }
 
 // CheckAll checks all resources availability. Blocks until all resources is checked
 func (a *httpAsker) CheckAll(ctx context.Context) error {
 	var wg sync.WaitGroup
 
-	for _, site := range a.SitesService.GetAll() {
+	// sites set may change between cycles
+	ss := a.getAll()
+
+	var step time.Duration
+	if a.spread && len(ss) > 0 {
+		step = a.rate / time.Duration(len(ss))
+	}
+
+	for i, site := range ss {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 			wg.Add(1)
-			go a.checkSite(ctx, site, &wg)
+			go func(site *sites.Site, delay time.Duration) {
+				defer wg.Done()
+				a.delayedCheckSite(ctx, site, delay)
+			}(site, time.Duration(i)*step)
 		}
 	}
 	wg.Wait()
 
 	return nil
-
 }
 
-// Get returns resource status by it's name
+// Get returns resource status by it's name along with it's uptime
 func (a *httpAsker) Get(ctx context.Context, name string) (r Response, err error) {
-	for _, site := range a.SitesService.GetAll() {
-		if site.Name == name {
-			a.MetricsRegistry.Counters[site.Name].Inc()
-			return Response{site.Name, site.Alive, site.Latency}, nil
-		}
+	site, err := a.findSite(name)
+	if err != nil {
+		return r, err
+	}
+
+	a.MetricsRegistry.Inc(site.Name)
+
+	r = a.response(site)
+	if a.uptime == nil {
+		return r, nil
+	}
+	uptime, err := a.uptime.Get(site.Name, time.Now())
+	if err != nil {
+		log.Printf("[ERROR] failed to calc %s site uptime: %+v", site.Name, err)
+		return r, nil
+	}
+	r.Uptime = make(map[string]float64, len(uptime))
+	for _, u := range uptime {
+		r.Uptime[u.Window] = u.Percent
 	}
 
-	return r, &NotFoundError{name}
+	return r, nil
 }
 
 // GetMin returns available resource with minimum latency
@@ -99,9 +208,9 @@ func (a *httpAsker) GetMin(ctx context.Context) (r Response, err error) {
 	}
 	min := sorted[0]
 
-	a.MetricsRegistry.Counters[min.Name].Inc()
+	a.MetricsRegistry.Inc(min.Name)
 
-	return Response{Name: min.Name, Alive: true, Latency: min.Latency}, nil
+	return a.response(min), nil
 }
 
 // GetMax returns available resource with maximum latency
@@ -113,9 +222,9 @@ func (a *httpAsker) GetMax(ctx context.Context) (r Response, err error) {
 
 	max := sorted[len(sorted)-1]
 
-	a.MetricsRegistry.Counters[max.Name].Inc()
+	a.MetricsRegistry.Inc(max.Name)
 
-	return Response{Name: max.Name, Alive: true, Latency: max.Latency}, nil
+	return a.response(max), nil
 }
 
 // GetRandom returns random available resource status response
@@ -129,34 +238,290 @@ func (a *httpAsker) GetRandom(ctx context.Context) (r Response, err error) {
 	n := rand.Int() % len(sites)
 	site := sites[n]
 
-	a.MetricsRegistry.Counters[site.Name].Inc()
+	a.MetricsRegistry.Inc(site.Name)
+
+	return a.response(site), nil
+}
+
+// Uptime returns resource uptime over rolling windows
+func (a *httpAsker) Uptime(ctx context.Context, name string) ([]history.Uptime, error) {
+	site, err := a.findSite(name)
+	if err != nil {
+		return nil, err
+	}
+	if a.uptime == nil {
+		return []history.Uptime{}, nil
+	}
+
+	return a.uptime.Get(site.Name, time.Now())
+}
+
+// Latency returns resource latency statistics over rolling window
+func (a *httpAsker) Latency(ctx context.Context, name string) (r LatencyStats, err error) {
+	site, err := a.findSite(name)
+	if err != nil {
+		return r, err
+	}
 
-	return Response{Name: site.Name, Alive: site.Alive, Latency: site.Latency}, nil
+	return a.latencies.stats(site.Name, time.Now()), nil
+}
+
+// Certificates returns certificates expiring within given period sorted by expiry time
+func (a *httpAsker) Certificates(ctx context.Context, within time.Duration) ([]CertificateStatus, error) {
+	now := time.Now()
+	res := []CertificateStatus{}
+	for _, site := range a.SitesService.GetAll() {
+		cert := site.Status().Certificate
+		if cert == nil || cert.NotAfter.Sub(now) >= within {
+			continue
+		}
+		res = append(res, newCertificateStatus(site.Name, cert, now))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].NotAfter.Before(res[j].NotAfter)
+	})
+
+	return res, nil
 }
 
 // nothing to finalize
 func (a *httpAsker) Close() {}
 
-func (a *httpAsker) checkSite(ctx context.Context, site *sites.Site, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (a *httpAsker) findSite(name string) (*sites.Site, error) {
+	for _, site := range a.SitesService.GetAll() {
+		if site.Name == name {
+			return site, nil
+		}
+	}
+
+	return nil, &NotFoundError{name}
+}
+
+// response builds resource status response, uptime is left out as it's costly to calc for many resources
+func (a *httpAsker) response(site *sites.Site) Response {
+	status := site.Status()
+	r := Response{
+		Name:      site.Name,
+		Alive:     status.Alive,
+		Latency:   status.Latency,
+		CheckedAt: status.CheckedAt,
+		ChangedAt: status.ChangedAt,
+		Error:     status.Error,
+		QueueWait: status.QueueWait,
+		Timings:   status.Timings,
+		Addresses: status.Addresses,
+
+		ConsecutiveFailures: status.ConsecutiveFailures,
+		Degraded:            status.Alive && status.Degraded,
+	}
+	if status.Certificate != nil {
+		cert := newCertificateStatus("", status.Certificate, time.Now())
+		r.Certificate = &cert
+	}
+
+	return r
+}
+
+// readBody reads response body up to assertions max body size.
+// Body is drained and omitted if there is nothing to verify in it
+func readBody(r io.Reader, assertions *sites.Assertions) ([]byte, error) {
+	var max int64
+	if assertions != nil {
+		max = assertions.MaxBodySize
+	}
+	if max > 0 {
+		r = io.LimitReader(r, max+1)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", site.Url.String(), nil)
+	if !assertions.ChecksBody() {
+		n, err := io.Copy(ioutil.Discard, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %v", err)
+		}
+		if max > 0 && n > max {
+			return nil, fmt.Errorf("body exceeds %d bytes", max)
+		}
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(r)
 	if err != nil {
-		log.Printf("[ERROR] failed to make request to %s site: %+v", site.Url.String(), err)
-		site.MarkUnavailable()
+		return nil, fmt.Errorf("failed to read body: %v", err)
+	}
+	if max > 0 && int64(len(body)) > max {
+		return nil, fmt.Errorf("body exceeds %d bytes", max)
+	}
+
+	return body, nil
+}
+
+func (a *httpAsker) emit(ev Event) {
+	if ev.Changed() {
+		log.Printf("[INFO] %s site is %s, was %s", ev.Site, ev.Current, ev.Previous)
+	}
+	for _, l := range a.listeners {
+		l.OnEvent(ev)
+	}
+}
+
+func (a *httpAsker) saveStatus(site *sites.Site) {
+	if err := a.SitesService.Save(site); err != nil {
+		log.Printf("[ERROR] failed to save %s site status: %+v", site.Name, err)
+	}
+}
+
+func (a *httpAsker) saveHistory(site *sites.Site, res sites.Result) {
+	if a.history == nil {
 		return
 	}
+	if err := a.history.Append(history.Record{Site: site.Name, Result: res}); err != nil {
+		log.Printf("[ERROR] failed to save %s site check result: %+v", site.Name, err)
+	}
+}
+
+// getAll returns current sites and syncs their metrics
+func (a *httpAsker) getAll() []*sites.Site {
+	ss := a.SitesService.GetAll()
+	a.syncSites(ss)
+
+	return ss
+}
+
+// syncSites registers counters of new sites and drops counters and stats of removed ones
+func (a *httpAsker) syncSites(ss []*sites.Site) {
+	names := make([]string, 0, len(ss))
+	for _, site := range ss {
+		names = append(names, site.Name)
+	}
+	a.MetricsRegistry.Sync(names)
+	a.latencies.sync(names)
+}
+
+// delayedCheckSite checks site after delay
+func (a *httpAsker) delayedCheckSite(ctx context.Context, site *sites.Site, delay time.Duration) {
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	a.checkSite(ctx, site, nil)
+}
+
+// checkSite checks site once concurrency limits allow it.
+// Slot acquired already is released after the first attempt, nil if none is acquired
+func (a *httpAsker) checkSite(ctx context.Context, site *sites.Site, release func()) {
+	res, ok := a.askWithRetries(ctx, site, release)
+	if !ok {
+		return
+	}
+	if a.certWarning > 0 && res.Certificate != nil {
+		res.Degraded = res.Certificate.NotAfter.Sub(res.CheckedAt) < a.certWarning
+	}
+	if res.Error != "" {
+		log.Printf("[ERROR] %s site check failed: %s", site.Url.String(), res.Error)
+	}
+
+	prev, cur := site.Record(res, a.hysteresis)
+	a.emit(Event{Site: site.Name, Tags: site.Tags, Previous: prev.State(), Current: cur.State(), Result: res})
+	if res.Alive {
+		a.latencies.add(site.Name, res.CheckedAt, res.Latency)
+	}
+	a.MetricsRegistry.ObserveCheck(site.Name, res.Alive, res.Latency)
+	a.MetricsRegistry.SetUp(site.Name, cur.Alive)
+	a.saveStatus(site)
+	a.saveHistory(site, res)
+}
+
+// askWithRetries retries failed requests with exponential backoff and returns the last result.
+// Concurrency slot is held during attempts only, ok is false if the first attempt didn't get it
+func (a *httpAsker) askWithRetries(ctx context.Context, site *sites.Site, release func()) (res sites.Result, ok bool) {
+	var wait time.Duration
+	backoff := a.retryBackoff
+	for attempt := 1; ; attempt++ {
+		if release == nil {
+			queued := time.Now()
+			var err error
+			if release, err = a.limiter.acquire(ctx, site.Url.Hostname()); err != nil {
+				return res, attempt > 1
+			}
+			wait += time.Since(queued)
+		}
+
+		res = a.check(ctx, site)
+		release()
+		release = nil
+		res.Attempts = attempt
+		res.QueueWait = wait
+		if res.Alive || attempt > a.retries {
+			return res, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return res, true
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// ask makes request to http site and returns check result
+func (a *httpAsker) ask(ctx context.Context, site *sites.Site) (res sites.Result) {
+	res.CheckedAt = time.Now()
+
+	var body io.Reader
+	if site.Body != "" {
+		body = strings.NewReader(site.Body)
+	}
+
+	trace := &tracer{}
+	ctx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+
+	req, err := http.NewRequestWithContext(ctx, site.Method, site.Url.String(), body)
+	if err != nil {
+		res.Error = fmt.Sprintf("failed to make request: %v", err)
+		return res
+	}
+	for k, v := range site.Headers {
+		req.Header.Set(k, v)
+	}
 
 	start := time.Now()
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
-		log.Printf("[ERROR] request to %s site failed: %+v", site.Url.String(), err)
-		site.MarkUnavailable()
-		return
+		// partial timings tell which phase failed
+		res.Timings = trace.timings(time.Now())
+		res.Error = fmt.Sprintf("request failed: %v", err)
+		return res
 	}
-	// omit reps body & code
 	defer resp.Body.Close()
-	_, err = ioutil.ReadAll(resp.Body)
 
-	site.MarkAvailable(time.Since(start))
+	res.Certificate = certificate(resp.TLS)
+	res.StatusCode = resp.StatusCode
+	if err := site.Assertions.CheckStatus(resp.StatusCode); err != nil {
+		res.Latency = time.Since(start)
+		res.Timings = trace.timings(time.Now())
+		res.Error = err.Error()
+		return res
+	}
+
+	respBody, err := readBody(resp.Body, site.Assertions)
+	end := time.Now()
+	res.Latency = end.Sub(start)
+	res.Timings = trace.timings(end)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	if err := site.Assertions.CheckBody(respBody); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Alive = true
+	return res
 }
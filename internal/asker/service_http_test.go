@@ -5,12 +5,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/mullakhmetov/status-board/internal/history"
 	"github.com/mullakhmetov/status-board/internal/metrics"
 	"github.com/mullakhmetov/status-board/internal/sites"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAsker_NewHttpAsker(t *testing.T) {
@@ -21,6 +26,7 @@ func TestAsker_NewHttpAsker(t *testing.T) {
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 
 	mockedMetrics := metrics.Registry{
 		InitCounterFunc: func(name string) metrics.Counter {
@@ -30,7 +36,7 @@ func TestAsker_NewHttpAsker(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	_ = NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Second)
+	_ = NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second})
 	assert.Equal(t, len(ss), len(mockedMetrics.Counters))
 	mockedSites.AssertExpectations(t)
 }
@@ -52,12 +58,13 @@ func TestAsker_Run(t *testing.T) {
 	assert.NoError(t, err)
 
 	ss := []*sites.Site{
-		&sites.Site{Name: "google.com", Url: google, Alive: false},
-		&sites.Site{Name: "vk.com", Url: vk, Alive: false},
+		&sites.Site{Name: "google.com", Url: google},
+		&sites.Site{Name: "vk.com", Url: vk},
 	}
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 
 	mockedMetrics := metrics.Registry{
 		InitCounterFunc: func(name string) metrics.Counter {
@@ -67,7 +74,7 @@ func TestAsker_Run(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	a := NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Millisecond)
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Millisecond})
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 	a.Run(ctx)
@@ -95,12 +102,13 @@ func TestAsker_Run_Cancel(t *testing.T) {
 	assert.NoError(t, err)
 
 	ss := []*sites.Site{
-		&sites.Site{Name: "google.com", Url: google, Alive: false},
-		&sites.Site{Name: "vk.com", Url: vk, Alive: false},
+		&sites.Site{Name: "google.com", Url: google},
+		&sites.Site{Name: "vk.com", Url: vk},
 	}
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 
 	mockedMetrics := metrics.Registry{
 		InitCounterFunc: func(name string) metrics.Counter {
@@ -110,7 +118,7 @@ func TestAsker_Run_Cancel(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	a := NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Millisecond)
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Millisecond})
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	cancel()
 	a.Run(ctx)
@@ -138,12 +146,13 @@ func TestAsker_CheckAll(t *testing.T) {
 	assert.NoError(t, err)
 
 	ss := []*sites.Site{
-		&sites.Site{Name: "google.com", Url: google, Alive: false},
-		&sites.Site{Name: "vk.com", Url: vk, Alive: false},
+		&sites.Site{Name: "google.com", Url: google},
+		&sites.Site{Name: "vk.com", Url: vk},
 	}
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 
 	mockedMetrics := metrics.Registry{
 		InitCounterFunc: func(name string) metrics.Counter {
@@ -153,7 +162,7 @@ func TestAsker_CheckAll(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	a := NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Second)
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -161,7 +170,7 @@ func TestAsker_CheckAll(t *testing.T) {
 
 	// marked as alive
 	for _, s := range ss {
-		assert.Equal(t, s.Alive, true)
+		assert.Equal(t, s.Status().Alive, true)
 	}
 }
 
@@ -182,12 +191,13 @@ func TestAsker_Get(t *testing.T) {
 	assert.NoError(t, err)
 
 	ss := []*sites.Site{
-		&sites.Site{Name: "google.com", Url: google, Alive: false},
-		&sites.Site{Name: "vk.com", Url: vk, Alive: false},
+		&sites.Site{Name: "google.com", Url: google},
+		&sites.Site{Name: "vk.com", Url: vk},
 	}
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 
 	mockedMetrics := metrics.Registry{
 		InitCounterFunc: func(name string) metrics.Counter {
@@ -197,7 +207,7 @@ func TestAsker_Get(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	a := NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Second)
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	a.CheckAll(ctx)
@@ -210,6 +220,15 @@ func TestAsker_Get(t *testing.T) {
 	resp, err = a.Get(ctx, "unknown.site")
 	assert.Error(t, err)
 	assert.Equal(t, resp.Alive, false)
+
+	stats, err := a.Latency(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Samples)
+	assert.Equal(t, time.Hour, stats.Window)
+	assert.Equal(t, stats.P50, stats.Max)
+
+	_, err = a.Latency(ctx, "unknown.site")
+	assert.IsType(t, &NotFoundError{}, err)
 }
 
 func TestAsker_CheckUnreachable(t *testing.T) {
@@ -220,12 +239,16 @@ func TestAsker_CheckUnreachable(t *testing.T) {
 	assert.NoError(t, err)
 
 	ss := []*sites.Site{
-		&sites.Site{Name: "google.com", Url: url, Alive: true},
-		&sites.Site{Name: "vk.com", Url: url, Alive: true},
+		&sites.Site{Name: "google.com", Url: url},
+		&sites.Site{Name: "vk.com", Url: url},
+	}
+	for _, s := range ss {
+		s.MarkAvailable(time.Millisecond)
 	}
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 
 	mockedMetrics := metrics.Registry{
 		InitCounterFunc: func(name string) metrics.Counter {
@@ -235,7 +258,7 @@ func TestAsker_CheckUnreachable(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	a := NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Second)
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	a.CheckAll(ctx)
@@ -269,12 +292,13 @@ func TestAsker_GetMin_GetMax_GetRandom(t *testing.T) {
 	assert.NoError(t, err)
 
 	ss := []*sites.Site{
-		&sites.Site{Name: "google.com", Url: google, Alive: false},
-		&sites.Site{Name: "vk.com", Url: vk, Alive: false},
+		&sites.Site{Name: "google.com", Url: google},
+		&sites.Site{Name: "vk.com", Url: vk},
 	}
 
 	mockedSites := new(sites.MockedService)
 	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
 	mockedSites.On("GetSortedByLatency").Return(ss)
 
 	mockedMetrics := metrics.Registry{
@@ -285,7 +309,7 @@ func TestAsker_GetMin_GetMax_GetRandom(t *testing.T) {
 		Counters: make(map[string]metrics.Counter),
 	}
 
-	a := NewHttpAsker(mockedSites, &mockedMetrics, time.Second, time.Second)
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	a.CheckAll(ctx)
@@ -304,3 +328,629 @@ func TestAsker_GetMin_GetMax_GetRandom(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, resp.Alive, true)
 }
+
+func TestAsker_CheckUnexpectedStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "HEAD", r.Method)
+		assert.Equal(t, "status-board", r.Header.Get("User-Agent"))
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	assertions, err := sites.NewAssertions(sites.Expectation{Status: []string{"200"}})
+	assert.NoError(t, err)
+	loose, err := sites.NewAssertions(sites.Expectation{Status: []string{"5xx"}})
+	assert.NoError(t, err)
+
+	ss := []*sites.Site{
+		&sites.Site{Name: "strict", Url: url, Method: "HEAD", Headers: map[string]string{"User-Agent": "status-board"}, Assertions: assertions},
+		&sites.Site{Name: "loose", Url: url, Method: "HEAD", Headers: map[string]string{"User-Agent": "status-board"}, Assertions: loose},
+		&sites.Site{Name: "default", Url: url, Method: "HEAD", Headers: map[string]string{"User-Agent": "status-board"}},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil).Maybe()
+
+	mockedMetrics := metrics.Registry{
+		InitCounterFunc: func(name string) metrics.Counter {
+			mockedCounter := new(metrics.MockedCounter)
+			return mockedCounter
+		},
+		Counters: make(map[string]metrics.Counter),
+	}
+
+	a := NewHttpAsker(mockedSites, &mockedMetrics, Opts{Timeout: time.Second, Rate: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.CheckAll(ctx)
+
+	assert.False(t, ss[0].Status().Alive)
+	assert.True(t, ss[1].Status().Alive)
+	// server error fails site without expectation
+	assert.False(t, ss[2].Status().Alive)
+	assert.Equal(t, "unexpected status 503", ss[2].Status().Error)
+}
+
+func TestAsker_CheckAll_SitesChanged(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	google := &sites.Site{Name: "google.com", Url: url}
+	vk := &sites.Site{Name: "vk.com", Url: url}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{google}).Once()
+	mockedSites.On("GetAll").Return([]*sites.Site{vk})
+	mockedSites.On("Save", vk).Return(nil)
+
+	registry := metrics.NewRegistry(false)
+
+	a := NewHttpAsker(mockedSites, registry, Opts{Timeout: time.Second, Rate: time.Second})
+	_, ok := registry.Get("google.com")
+	assert.True(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.CheckAll(ctx)
+
+	_, ok = registry.Get("google.com")
+	assert.False(t, ok)
+	_, ok = registry.Get("vk.com")
+	assert.True(t, ok)
+	assert.True(t, vk.Status().Alive)
+}
+
+func TestAsker_CheckAll_History(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	assertions, err := sites.NewAssertions(sites.Expectation{Status: []string{"2xx"}})
+	assert.NoError(t, err)
+
+	ss := []*sites.Site{
+		&sites.Site{Name: "google.com", Url: url, Assertions: assertions},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	h := history.NewMemoryHistory(time.Hour)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second, Rate: time.Second, History: h})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.CheckAll(ctx)
+	a.CheckAll(ctx)
+
+	records, err := h.Range("google.com", time.Now().Add(-time.Minute), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.False(t, records[0].Alive)
+	assert.Equal(t, 503, records[0].StatusCode)
+	assert.Equal(t, "unexpected status 503", records[0].Error)
+
+	resp, err := a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"1h": 0, "24h": 0, "7d": 0, "30d": 0}, resp.Uptime)
+
+	// uptime is calculated for single resource only
+	resp, err = a.GetRandom(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Uptime)
+
+	uptime, err := a.Uptime(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(uptime))
+	assert.Equal(t, 2, uptime[0].Checks)
+
+	_, err = a.Uptime(ctx, "unknown.site")
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestAsker_CheckAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status": "ok", "checks": [{"name": "db", "up": false}]}`))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		exp   sites.Expectation
+		alive bool
+		err   string
+	}{
+		{sites.Expectation{Status: []string{"200-204"}, BodyContains: `"ok"`}, true, ""},
+		{sites.Expectation{Status: []string{"3xx"}}, false, "unexpected status 200"},
+		{sites.Expectation{BodyContains: "degraded"}, false, `body doesn't contain "degraded"`},
+		{sites.Expectation{BodyRegex: `"status":\s*"ok"`}, true, ""},
+		{sites.Expectation{BodyRegex: `^ok`}, false, `body doesn't match "^ok"`},
+		{sites.Expectation{JSON: map[string]interface{}{"status": "ok"}}, true, ""},
+		{sites.Expectation{JSON: map[string]interface{}{"checks.0.up": true}}, false, "json field checks.0.up is false, true expected"},
+		{sites.Expectation{JSON: map[string]interface{}{"checks.1.up": true}}, false, "json field checks.1.up not found"},
+		{sites.Expectation{MaxBodySize: 10}, false, "body exceeds 10 bytes"},
+		{sites.Expectation{MaxBodySize: 10, BodyContains: "status"}, false, "body exceeds 10 bytes"},
+		{sites.Expectation{MaxBodySize: 1024, BodyContains: "status"}, true, ""},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{})
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second}).(*httpAsker)
+
+	for _, c := range cases {
+		assertions, err := sites.NewAssertions(c.exp)
+		assert.NoError(t, err)
+
+		res := a.ask(context.Background(), &sites.Site{Name: "api", Url: url, Assertions: assertions})
+		assert.Equal(t, c.alive, res.Alive, c.err)
+		assert.Equal(t, c.err, res.Error)
+		assert.Equal(t, 200, res.StatusCode)
+	}
+}
+
+type eventsRecorder struct {
+	lock   sync.Mutex
+	events []Event
+}
+
+func (r *eventsRecorder) OnEvent(ev Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func TestAsker_CheckAll_Events(t *testing.T) {
+	var healthy int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(503)
+		}
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	assertions, err := sites.NewAssertions(sites.Expectation{Status: []string{"200"}})
+	assert.NoError(t, err)
+	site := &sites.Site{Name: "google.com", Url: url, Assertions: assertions}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{site})
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	recorder := &eventsRecorder{}
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second, Listeners: []Listener{recorder}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.CheckAll(ctx)
+	atomic.StoreInt32(&healthy, 0)
+	a.CheckAll(ctx)
+	a.CheckAll(ctx)
+
+	assert.Equal(t, 3, len(recorder.events))
+	assert.Equal(t, sites.StateUnknown, recorder.events[0].Previous)
+	assert.Equal(t, sites.StateUp, recorder.events[0].Current)
+	assert.False(t, recorder.events[0].Changed())
+
+	assert.Equal(t, sites.StateUp, recorder.events[1].Previous)
+	assert.Equal(t, sites.StateDown, recorder.events[1].Current)
+	assert.Equal(t, "unexpected status 503", recorder.events[1].Result.Error)
+	assert.True(t, recorder.events[1].Changed())
+
+	assert.False(t, recorder.events[2].Changed())
+}
+
+func TestEvent_Changed(t *testing.T) {
+	assert.True(t, Event{Previous: sites.StateUnknown, Current: sites.StateDown}.Changed())
+	assert.False(t, Event{Previous: sites.StateUnknown, Current: sites.StateUp}.Changed())
+	assert.True(t, Event{Previous: sites.StateDown, Current: sites.StateUp}.Changed())
+	assert.False(t, Event{Previous: sites.StateDown, Current: sites.StateDown}.Changed())
+	assert.True(t, Event{Previous: sites.StateUnknown, Current: sites.StateDegraded}.Changed())
+	assert.True(t, Event{Previous: sites.StateUp, Current: sites.StateDegraded}.Changed())
+}
+
+func TestAsker_ConcurrentChecksAndReads(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	ss := []*sites.Site{
+		&sites.Site{Name: "google.com", Url: url},
+		&sites.Site{Name: "vk.com", Url: url},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("GetSortedByLatency").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(false), Opts{Timeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			a.CheckAll(ctx)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			resp, err := a.Get(ctx, "vk.com")
+			assert.NoError(t, err)
+			assert.True(t, resp.Alive)
+			assert.False(t, resp.CheckedAt.IsZero())
+			return
+		default:
+			_, err := a.Get(ctx, "google.com")
+			assert.NoError(t, err)
+			_, err = a.GetMin(ctx)
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestAsker_CheckAll_Concurrency(t *testing.T) {
+	var active, maxActive int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	var ss []*sites.Site
+	for i := 0; i < 6; i++ {
+		ss = append(ss, &sites.Site{Name: strconv.Itoa(i), Url: url})
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second, Concurrency: 4, HostConcurrency: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.CheckAll(ctx)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxActive))
+
+	var waited int
+	for _, site := range ss {
+		status := site.Status()
+		assert.True(t, status.Alive)
+		// latency excludes time spent in queue
+		assert.True(t, status.Latency < 40*time.Millisecond)
+		if status.QueueWait >= 20*time.Millisecond {
+			waited++
+		}
+	}
+	assert.Equal(t, 4, waited)
+}
+
+func TestAsker_CheckAll_Spread(t *testing.T) {
+	var lock sync.Mutex
+	var starts []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		starts = append(starts, time.Now())
+		lock.Unlock()
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	ss := []*sites.Site{
+		&sites.Site{Name: "google.com", Url: url},
+		&sites.Site{Name: "vk.com", Url: url},
+	}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return(ss)
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second, Rate: 200 * time.Millisecond, Spread: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.CheckAll(ctx)
+
+	assert.Equal(t, 2, len(starts))
+	assert.True(t, starts[1].Sub(starts[0]) >= 90*time.Millisecond)
+}
+
+func TestAsker_CheckAll_Retries(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// every third request succeeds
+		if atomic.AddInt32(&requests, 1)%3 != 0 {
+			w.WriteHeader(503)
+		}
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	assertions, err := sites.NewAssertions(sites.Expectation{Status: []string{"200"}})
+	assert.NoError(t, err)
+	site := &sites.Site{Name: "google.com", Url: url, Assertions: assertions}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{site})
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	historyService := history.NewMemoryHistory(time.Hour)
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{
+		Timeout:      time.Second,
+		History:      historyService,
+		Retries:      2,
+		RetryBackoff: 10 * time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	a.CheckAll(ctx)
+	// backoff is doubled after every retry
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+	assert.True(t, site.Status().Alive)
+
+	a.(*httpAsker).retries = 1
+	a.CheckAll(ctx)
+	assert.False(t, site.Status().Alive)
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+
+	records, err := historyService.Range("google.com", start, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, 3, records[0].Attempts)
+	assert.Equal(t, 2, records[1].Attempts)
+	assert.Equal(t, "unexpected status 503", records[1].Error)
+}
+
+func TestAsker_CheckAll_RetriesReleaseSlot(t *testing.T) {
+	failed := make(chan struct{}, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(503)
+			failed <- struct{}{}
+		}
+	}))
+	defer ts.Close()
+
+	downURL, err := url.Parse(ts.URL + "/down")
+	assert.NoError(t, err)
+	upURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	down := &sites.Site{Name: "down", Url: downURL}
+	up := &sites.Site{Name: "up", Url: upURL}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{down})
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{
+		Timeout:      time.Second,
+		Concurrency:  1,
+		Retries:      1,
+		RetryBackoff: 200 * time.Millisecond,
+	}).(*httpAsker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		a.CheckAll(ctx)
+		close(done)
+	}()
+	<-failed
+
+	// slot isn't held while failed check backs off
+	a.checkSite(ctx, up, nil)
+	assert.True(t, up.Status().Alive)
+	assert.True(t, up.Status().QueueWait < 100*time.Millisecond)
+
+	<-done
+	assert.False(t, down.Status().Alive)
+}
+
+func TestAsker_CheckAll_Hysteresis(t *testing.T) {
+	var healthy int32 = 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(503)
+		}
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+
+	assertions, err := sites.NewAssertions(sites.Expectation{Status: []string{"200"}})
+	assert.NoError(t, err)
+	site := &sites.Site{Name: "google.com", Url: url, Assertions: assertions}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{site})
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	recorder := &eventsRecorder{}
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{
+		Timeout:    time.Second,
+		Listeners:  []Listener{recorder},
+		Hysteresis: sites.Hysteresis{Down: 2, Up: 2},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.CheckAll(ctx)
+	atomic.StoreInt32(&healthy, 0)
+	a.CheckAll(ctx)
+
+	r, err := a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.True(t, r.Alive)
+	assert.Equal(t, 1, r.ConsecutiveFailures)
+	assert.Equal(t, "unexpected status 503", r.Error)
+
+	a.CheckAll(ctx)
+	r, err = a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.False(t, r.Alive)
+	assert.Equal(t, 2, r.ConsecutiveFailures)
+
+	atomic.StoreInt32(&healthy, 1)
+	a.CheckAll(ctx)
+	a.CheckAll(ctx)
+
+	changed := []bool{}
+	for _, ev := range recorder.events {
+		changed = append(changed, ev.Changed())
+	}
+	assert.Equal(t, []bool{false, false, true, false, true}, changed)
+	assert.Equal(t, sites.StateUp, site.State())
+}
+
+func TestAsker_CheckTimings(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		_, err := w.Write([]byte("hello "))
+		assert.NoError(t, err)
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		_, err = w.Write([]byte("world"))
+		assert.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	url, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	// resolve name to trace dns lookup
+	url.Host = "localhost:" + url.Port()
+
+	assertions, err := sites.NewAssertions(sites.Expectation{BodyContains: "world"})
+	assert.NoError(t, err)
+	site := &sites.Site{Name: "google.com", Url: url, Assertions: assertions}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{site})
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second})
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	// test certificate is issued for example.com
+	tlsConfig.ServerName = "example.com"
+	a.(*httpAsker).httpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.CheckAll(ctx)
+	r, err := a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.True(t, r.Alive, r.Error)
+	assert.True(t, r.Timings.DNS > 0)
+	assert.True(t, r.Timings.Connect > 0)
+	assert.True(t, r.Timings.TLS > 0)
+	assert.True(t, r.Timings.TTFB >= 30*time.Millisecond)
+	// the first byte may be noticed late
+	assert.True(t, r.Timings.Transfer >= 25*time.Millisecond)
+	assert.True(t, r.Latency >= r.Timings.DNS+r.Timings.Connect+r.Timings.TLS+r.Timings.TTFB+r.Timings.Transfer)
+
+	// connection isn't reused, all phases are measured again
+	a.CheckAll(ctx)
+	r, err = a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.True(t, r.Timings.DNS > 0)
+	assert.True(t, r.Timings.Connect > 0)
+	assert.True(t, r.Timings.TLS > 0)
+	assert.True(t, r.Timings.TTFB >= 30*time.Millisecond)
+}
+
+func TestAsker_CheckCertificate(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	tlsURL, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	plainURL, err := url.Parse(plain.URL)
+	assert.NoError(t, err)
+
+	secure := &sites.Site{Name: "google.com", Url: tlsURL}
+	insecure := &sites.Site{Name: "vk.com", Url: plainURL}
+
+	mockedSites := new(sites.MockedService)
+	mockedSites.On("GetAll").Return([]*sites.Site{secure, insecure})
+	mockedSites.On("Save", mock.Anything).Return(nil)
+
+	a := NewHttpAsker(mockedSites, metrics.NewRegistry(true), Opts{Timeout: time.Second})
+	a.(*httpAsker).httpClient.Transport.(*http.Transport).TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.CheckAll(ctx)
+	r, err := a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.True(t, r.Alive)
+	assert.False(t, r.Degraded)
+	assert.Equal(t, "O=Acme Co", r.Certificate.Issuer)
+	assert.Contains(t, r.Certificate.DNSNames, "example.com")
+	assert.Equal(t, ts.Certificate().NotAfter, r.Certificate.NotAfter)
+	assert.Equal(t, int(time.Until(ts.Certificate().NotAfter).Hours()/24), r.Certificate.DaysToExpiry)
+
+	r, err = a.Get(ctx, "vk.com")
+	assert.NoError(t, err)
+	assert.Nil(t, r.Certificate)
+
+	certs, err := a.Certificates(ctx, 30*24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(certs))
+
+	certs, err = a.Certificates(ctx, time.Until(ts.Certificate().NotAfter)+time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(certs))
+	assert.Equal(t, "google.com", certs[0].Name)
+
+	// test certificate expires within a century
+	a.(*httpAsker).certWarning = 100 * 365 * 24 * time.Hour
+	a.CheckAll(ctx)
+	r, err = a.Get(ctx, "google.com")
+	assert.NoError(t, err)
+	assert.True(t, r.Alive)
+	assert.True(t, r.Degraded)
+	assert.Equal(t, sites.StateDegraded, secure.State())
+	assert.Equal(t, sites.StateUp, insecure.State())
+}
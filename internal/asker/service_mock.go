@@ -2,7 +2,9 @@ package asker
 
 import (
 	"context"
+	"time"
 
+	"github.com/mullakhmetov/status-board/internal/history"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -40,4 +42,24 @@ func (m *MockedService) GetRandom(ctx context.Context) (Response, error) {
 	return args.Get(0).(Response), args.Error(1)
 }
 
+func (m *MockedService) List(ctx context.Context, q ListQuery) (ListPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(ListPage), args.Error(1)
+}
+
+func (m *MockedService) Uptime(ctx context.Context, name string) ([]history.Uptime, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).([]history.Uptime), args.Error(1)
+}
+
+func (m *MockedService) Latency(ctx context.Context, name string) (LatencyStats, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(LatencyStats), args.Error(1)
+}
+
+func (m *MockedService) Certificates(ctx context.Context, within time.Duration) ([]CertificateStatus, error) {
+	args := m.Called(ctx, within)
+	return args.Get(0).([]CertificateStatus), args.Error(1)
+}
+
 func (m *MockedService) Close() {}
@@ -0,0 +1,79 @@
+package asker
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// tracer records request phases timestamps. Connect callbacks may be called concurrently
+// when several addresses are dialed, so it's guarded
+type tracer struct {
+	lock         sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart: func(network, addr string) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				t.set(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { t.set(&t.gotConn) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+func (t *tracer) set(ts *time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	*ts = time.Now()
+}
+
+// timings returns phases durations of request finished at end.
+// Phases not reached, e.g. tls handshake of plain http request, are zero
+func (t *tracer) timings(end time.Time) sites.Timings {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	res := sites.Timings{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connectStart, t.connectDone),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		TTFB:    between(t.gotConn, t.firstByte),
+	}
+	if !t.firstByte.IsZero() {
+		res.Transfer = between(t.firstByte, end)
+	}
+
+	return res
+}
+
+func between(from, to time.Time) time.Duration {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
@@ -0,0 +1,115 @@
+package badge
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mullakhmetov/status-board/internal/asker"
+)
+
+// maxAge is a number of seconds clients and proxies may cache badges for
+const maxAge = 60
+
+func RegisterHandlers(r *gin.Engine, service asker.Service) {
+	res := resource{service}
+
+	r.GET("/badge/:site", res.State)
+	r.GET("/badge/:site/latency.svg", res.Latency)
+	r.GET("/badge/:site/uptime.svg", res.Uptime)
+}
+
+type resource struct {
+	service asker.Service
+}
+
+// State renders `/badge/{site}.svg` badge of site state
+func (r *resource) State(c *gin.Context) {
+	name := c.Param("site")
+	if !strings.HasSuffix(name, ".svg") {
+		c.JSON(http.StatusNotFound, fmt.Sprintf("Unknown badge: %s", name))
+		return
+	}
+	r.render(c, strings.TrimSuffix(name, ".svg"), State)
+}
+
+// Latency renders badge of site last check latency
+func (r *resource) Latency(c *gin.Context) {
+	r.render(c, c.Param("site"), Latency)
+}
+
+// Uptime renders badge of site 30 days uptime
+func (r *resource) Uptime(c *gin.Context) {
+	r.render(c, c.Param("site"), Uptime)
+}
+
+func (r *resource) render(c *gin.Context, name string, badge func(asker.Response) Badge) {
+	status := http.StatusOK
+	var b Badge
+	res, err := r.service.Get(c, name)
+	switch err.(type) {
+	case nil:
+		b = badge(res)
+	case *asker.NotFoundError:
+		// badge is rendered anyway so that embedding page doesn't show broken image
+		status = http.StatusNotFound
+		b = Badge{Label: name, Message: "not found", Color: colorGrey}
+	default:
+		log.Printf("[ERROR] failed to get %s status for badge: %v", name, err)
+		status = http.StatusInternalServerError
+		b = Badge{Label: name, Message: "error", Color: colorGrey}
+	}
+
+	svg, err := b.Render()
+	if err != nil {
+		log.Printf("[ERROR] failed to render badge of %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, "unknown error")
+		return
+	}
+
+	if status != http.StatusOK {
+		c.Header("Cache-Control", "no-cache")
+		c.Data(status, "image/svg+xml; charset=utf-8", svg)
+		return
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(svg)
+	etag := fmt.Sprintf(`"%x"`, h.Sum64())
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	c.Header("ETag", etag)
+	if !res.CheckedAt.IsZero() {
+		c.Header("Last-Modified", res.CheckedAt.UTC().Format(http.TimeFormat))
+	}
+	if notModified(c.Request, etag, res.CheckedAt) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, "image/svg+xml; charset=utf-8", svg)
+}
+
+// notModified reports whether client's cached badge is still valid. If-Modified-Since is
+// considered only without If-None-Match, entity tags are compared weakly as GET allows
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	// Last-Modified has seconds precision
+	return !modified.Truncate(time.Second).After(since)
+}
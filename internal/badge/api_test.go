@@ -0,0 +1,136 @@
+package badge
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+)
+
+func TestBadges(t *testing.T) {
+	router, ms := setupRouter()
+
+	res := asker.Response{
+		Name:      "google.com",
+		Alive:     true,
+		Latency:   120 * time.Millisecond,
+		CheckedAt: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC),
+		Uptime:    map[string]float64{"30d": 99.5},
+	}
+	ms.On("Get", mock.AnythingOfType("*gin.Context"), "google.com").Return(res, nil)
+
+	for path, text := range map[string]string{
+		"/badge/google.com.svg":         ">up</text>",
+		"/badge/google.com/latency.svg": ">120 ms</text>",
+		"/badge/google.com/uptime.svg":  ">99.50%</text>",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code, path)
+		assert.Equal(t, "image/svg+xml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Sun, 01 Mar 2020 12:00:00 GMT", w.Header().Get("Last-Modified"))
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), text, path)
+	}
+	ms.AssertExpectations(t)
+}
+
+func TestBadges_NotModified(t *testing.T) {
+	router, ms := setupRouter()
+	ms.On("Get", mock.AnythingOfType("*gin.Context"), "google.com").Return(asker.Response{Name: "google.com"}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/badge/google.com.svg", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	etag := w.Header().Get("ETag")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/badge/google.com.svg", nil)
+	req.Header.Set("If-None-Match", etag)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 304, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestBadges_Conditional(t *testing.T) {
+	router, ms := setupRouter()
+	checkedAt := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+	ms.On("Get", mock.AnythingOfType("*gin.Context"), "google.com").Return(asker.Response{Name: "google.com", CheckedAt: checkedAt}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/badge/google.com.svg", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	assert.Equal(t, "Thu, 02 Jan 2020 03:04:05 GMT", lastModified)
+
+	cases := []struct {
+		header, value string
+		code          int
+	}{
+		{"If-None-Match", "W/" + etag, 304},
+		{"If-None-Match", `"other", ` + etag, 304},
+		{"If-None-Match", "*", 304},
+		{"If-None-Match", `"other"`, 200},
+		{"If-Modified-Since", lastModified, 304},
+		{"If-Modified-Since", checkedAt.Add(-time.Second).Format(http.TimeFormat), 200},
+		{"If-Modified-Since", "yesterday", 200},
+	}
+	for _, tc := range cases {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/badge/google.com.svg", nil)
+		req.Header.Set(tc.header, tc.value)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, "%s: %s", tc.header, tc.value)
+	}
+
+	// If-Modified-Since is ignored along with If-None-Match
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/badge/google.com.svg", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	req.Header.Set("If-Modified-Since", lastModified)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestBadges_Errors(t *testing.T) {
+	router, ms := setupRouter()
+	ms.On("Get", mock.AnythingOfType("*gin.Context"), "unknown").Return(asker.Response{}, &asker.NotFoundError{})
+	ms.On("Get", mock.AnythingOfType("*gin.Context"), "broken").Return(asker.Response{}, errors.New("boom"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/badge/unknown.svg", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), ">not found</text>")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/badge/broken/uptime.svg", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 500, w.Code)
+	assert.Contains(t, w.Body.String(), ">error</text>")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/badge/google.com.png", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+}
+
+func setupRouter() (*gin.Engine, *asker.MockedService) {
+	r := gin.Default()
+	ms := new(asker.MockedService)
+	RegisterHandlers(r, ms)
+	return r, ms
+}
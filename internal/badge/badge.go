@@ -0,0 +1,155 @@
+// Package badge renders shields-style SVG badges of sites status.
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// Badge colors
+const (
+	colorGreen       = "#4c1"
+	colorYellowGreen = "#97ca00"
+	colorYellow      = "#dfb317"
+	colorOrange      = "#fe7d37"
+	colorRed         = "#e05d44"
+	colorGrey        = "#9f9f9f"
+)
+
+// uptimeWindow is a rolling window uptime badge shows
+const uptimeWindow = "30d"
+
+// Badge is a label and colored message, e.g. "google.com | up"
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// stateColors are colors of state badge
+var stateColors = map[sites.State]string{
+	sites.StateUp:       colorGreen,
+	sites.StateDegraded: colorYellow,
+	sites.StateDown:     colorRed,
+	sites.StateUnknown:  colorGrey,
+}
+
+// State returns badge of resource availability state
+func State(res asker.Response) Badge {
+	state := res.State()
+	return Badge{Label: res.Name, Message: string(state), Color: stateColors[state]}
+}
+
+// Latency returns badge of resource last check latency, unavailable resource has no latency
+func Latency(res asker.Response) Badge {
+	b := Badge{Label: "latency", Message: "n/a", Color: colorGrey}
+	if !res.Alive {
+		return b
+	}
+
+	b.Message = fmt.Sprintf("%d ms", res.Latency/time.Millisecond)
+	switch {
+	case res.Latency < 300*time.Millisecond:
+		b.Color = colorGreen
+	case res.Latency < time.Second:
+		b.Color = colorYellow
+	default:
+		b.Color = colorOrange
+	}
+	return b
+}
+
+// Uptime returns badge of resource uptime over the last 30 days
+func Uptime(res asker.Response) Badge {
+	b := Badge{Label: "uptime " + uptimeWindow, Message: "n/a", Color: colorGrey}
+	percent, ok := res.Uptime[uptimeWindow]
+	if !ok {
+		return b
+	}
+
+	b.Message = fmt.Sprintf("%.2f%%", percent)
+	switch {
+	case percent >= 99.9:
+		b.Color = colorGreen
+	case percent >= 99:
+		b.Color = colorYellowGreen
+	case percent >= 95:
+		b.Color = colorYellow
+	default:
+		b.Color = colorRed
+	}
+	return b
+}
+
+// badgeView is a template data of badge with text sizes in pixels
+type badgeView struct {
+	Badge
+	LabelWidth   int
+	MessageWidth int
+	Width        int
+	// LabelX and MessageX are centers of texts
+	LabelX   float64
+	MessageX float64
+}
+
+// padding is a horizontal space around badge texts
+const padding = 10
+
+var badgeTemplate = template.Must(template.New("badge").Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">` +
+		`<title>{{.Label}}: {{.Message}}</title>` +
+		`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+		`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+		`<g clip-path="url(#r)">` +
+		`<rect width="{{.LabelWidth}}" height="20" fill="#555"/>` +
+		`<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>` +
+		`<rect width="{{.Width}}" height="20" fill="url(#s)"/>` +
+		`</g>` +
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+		`<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text>` +
+		`<text x="{{.LabelX}}" y="14">{{.Label}}</text>` +
+		`<text x="{{.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{.Message}}</text>` +
+		`<text x="{{.MessageX}}" y="14">{{.Message}}</text>` +
+		`</g></svg>`,
+))
+
+// Render returns SVG image of badge
+func (b Badge) Render() ([]byte, error) {
+	v := badgeView{
+		Badge:        b,
+		LabelWidth:   textWidth(b.Label) + padding,
+		MessageWidth: textWidth(b.Message) + padding,
+	}
+	v.Width = v.LabelWidth + v.MessageWidth
+	v.LabelX = float64(v.LabelWidth) / 2
+	v.MessageX = float64(v.LabelWidth) + float64(v.MessageWidth)/2
+
+	var buf bytes.Buffer
+	if err := badgeTemplate.Execute(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// textWidth approximates width of text in 11px Verdana
+func textWidth(s string) int {
+	var w float64
+	for _, r := range s {
+		switch {
+		case r == 'i' || r == 'j' || r == 'l' || r == '.' || r == ',' || r == ':' || r == ';' || r == '!' || r == '|' || r == '\'':
+			w += 3.5
+		case r == ' ' || r == 'f' || r == 'r' || r == 't' || r == '-' || r == '(' || r == ')':
+			w += 4.5
+		case r == 'm' || r == 'w' || r == '%' || r >= 'A' && r <= 'Z':
+			w += 9.5
+		default:
+			w += 7
+		}
+	}
+	return int(w + 0.5)
+}
@@ -0,0 +1,60 @@
+package badge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+)
+
+func TestState(t *testing.T) {
+	now := time.Now()
+
+	b := State(asker.Response{Name: "google.com", CheckedAt: now, Alive: true})
+	assert.Equal(t, Badge{Label: "google.com", Message: "up", Color: colorGreen}, b)
+
+	b = State(asker.Response{Name: "google.com", CheckedAt: now, Alive: true, Degraded: true})
+	assert.Equal(t, Badge{Label: "google.com", Message: "degraded", Color: colorYellow}, b)
+
+	b = State(asker.Response{Name: "google.com", CheckedAt: now})
+	assert.Equal(t, Badge{Label: "google.com", Message: "down", Color: colorRed}, b)
+
+	b = State(asker.Response{Name: "google.com"})
+	assert.Equal(t, Badge{Label: "google.com", Message: "unknown", Color: colorGrey}, b)
+}
+
+func TestLatency(t *testing.T) {
+	b := Latency(asker.Response{Alive: true, Latency: 120 * time.Millisecond})
+	assert.Equal(t, Badge{Label: "latency", Message: "120 ms", Color: colorGreen}, b)
+
+	b = Latency(asker.Response{Alive: true, Latency: 1500 * time.Millisecond})
+	assert.Equal(t, Badge{Label: "latency", Message: "1500 ms", Color: colorOrange}, b)
+
+	b = Latency(asker.Response{Latency: 120 * time.Millisecond})
+	assert.Equal(t, Badge{Label: "latency", Message: "n/a", Color: colorGrey}, b)
+}
+
+func TestUptime(t *testing.T) {
+	b := Uptime(asker.Response{Uptime: map[string]float64{"24h": 100, "30d": 99.95}})
+	assert.Equal(t, Badge{Label: "uptime 30d", Message: "99.95%", Color: colorGreen}, b)
+
+	b = Uptime(asker.Response{Uptime: map[string]float64{"30d": 90}})
+	assert.Equal(t, Badge{Label: "uptime 30d", Message: "90.00%", Color: colorRed}, b)
+
+	b = Uptime(asker.Response{})
+	assert.Equal(t, Badge{Label: "uptime 30d", Message: "n/a", Color: colorGrey}, b)
+}
+
+func TestRender(t *testing.T) {
+	svg, err := Badge{Label: "<b>", Message: "up", Color: colorGreen}.Render()
+	assert.NoError(t, err)
+
+	s := string(svg)
+	assert.Contains(t, s, `<svg xmlns="http://www.w3.org/2000/svg" width="55" height="20"`)
+	assert.Contains(t, s, `<rect x="31" width="24" height="20" fill="#4c1"/>`)
+	assert.Contains(t, s, `<text x="15.5" y="14">&lt;b&gt;</text>`)
+	assert.Contains(t, s, `<text x="43" y="14">up</text>`)
+	assert.NotContains(t, s, "<b>")
+}
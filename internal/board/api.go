@@ -0,0 +1,55 @@
+package board
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mullakhmetov/status-board/internal/history"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+const (
+	// defaultRefresh is a page reload period in seconds
+	defaultRefresh = 30
+	// minRefresh protects checks history from too frequent page reloads
+	minRefresh = 5
+)
+
+func RegisterHandlers(r *gin.Engine, sitesService sites.Service, historyService history.Service, uptimeCache *history.UptimeCache) {
+	res := resource{sites: sitesService, history: historyService, uptime: uptimeCache}
+
+	r.GET("/", res.Index)
+}
+
+type resource struct {
+	sites   sites.Service
+	history history.Service
+	uptime  *history.UptimeCache
+}
+
+// Index renders status page of all sites reloading itself every `refresh` query param seconds, 30 by default
+func (r *resource) Index(c *gin.Context) {
+	refresh := defaultRefresh
+	if value := c.Query("refresh"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < minRefresh {
+			c.String(http.StatusBadRequest, "Invalid refresh %s, at least %d seconds expected", value, minRefresh)
+			return
+		}
+		refresh = n
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, newPage(r.sites.GetAll(), r.history, r.uptime, refresh, time.Now())); err != nil {
+		log.Printf("[ERROR] failed to render status page: %v", err)
+		c.String(http.StatusInternalServerError, "unknown error")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,86 @@
+package board
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/history"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+func TestIndex(t *testing.T) {
+	up := &sites.Site{Name: "google.com"}
+	up.Record(sites.Result{CheckedAt: time.Now(), Alive: true, Latency: 120 * time.Millisecond}, sites.Hysteresis{})
+	down := &sites.Site{Name: "vk.com"}
+	down.Record(sites.Result{CheckedAt: time.Now(), Error: "connection refused"}, sites.Hysteresis{})
+	unknown := &sites.Site{Name: "<script>"}
+
+	h := history.NewMemoryHistory(24 * time.Hour)
+	now := time.Now()
+	for i := 3; i > 0; i-- {
+		err := h.Append(history.Record{Site: "google.com", Result: sites.Result{
+			CheckedAt: now.Add(-time.Duration(i) * time.Minute),
+			Alive:     i != 2,
+			Latency:   time.Duration(i) * 100 * time.Millisecond,
+		}})
+		assert.NoError(t, err)
+	}
+
+	router, ms := setupRouter(h)
+	ms.On("GetAll").Return([]*sites.Site{up, down, unknown})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, `<meta http-equiv="refresh" content="30">`)
+	assert.Contains(t, body, "1/3 up")
+	assert.Contains(t, body, `<div class="card up">`)
+	assert.Contains(t, body, `<div class="card down">`)
+	assert.Contains(t, body, `<div class="card unknown">`)
+	assert.Contains(t, body, "120 ms")
+	assert.Contains(t, body, "66.67%")
+	assert.Contains(t, body, "connection refused")
+	assert.Contains(t, body, "<polyline points=")
+	assert.Contains(t, body, "<circle ")
+	assert.Contains(t, body, "&lt;script&gt;")
+	assert.NotContains(t, body, "<script>")
+
+	// sites down go first
+	assert.True(t, strings.Index(body, "vk.com") < strings.Index(body, "google.com"))
+}
+
+func TestIndex_Refresh(t *testing.T) {
+	router, ms := setupRouter(history.NewMemoryHistory(time.Hour))
+	ms.On("GetAll").Return([]*sites.Site{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?refresh=10", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `<meta http-equiv="refresh" content="10">`)
+	assert.Contains(t, w.Body.String(), "No sites configured")
+
+	for _, value := range []string{"1", "soon"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/?refresh="+value, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 400, w.Code)
+	}
+}
+
+func setupRouter(h history.Service) (*gin.Engine, *sites.MockedService) {
+	r := gin.Default()
+	ms := new(sites.MockedService)
+	RegisterHandlers(r, ms, h, history.NewUptimeCache(h, time.Minute))
+	return r, ms
+}
@@ -0,0 +1,204 @@
+// Package board renders sites status as a self-contained HTML page.
+package board
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/history"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+const (
+	// uptimeWindow is a history.UptimeWindows name uptime is shown for
+	uptimeWindow = "24h"
+	// sparkWindow is a period of the latest checks drawn in sparkline
+	sparkWindow = time.Hour
+	// sparkPoints is a maximum number of the latest checks drawn in sparkline
+	sparkPoints = 60
+
+	sparkWidth   = 120
+	sparkHeight  = 28
+	sparkPadding = 2
+)
+
+// page is a template data of status page
+type page struct {
+	Refresh     int
+	GeneratedAt string
+	Total       int
+	Up          int
+	Down        int
+	Sites       []siteCard
+}
+
+// siteCard is a single site summary
+type siteCard struct {
+	Name       string
+	State      sites.State
+	Latency    string
+	CheckedAt  string
+	CheckedAgo string
+	Uptime     string
+	Error      string
+	Sparkline  sparkline
+}
+
+// sparkline is an inline svg chart of recent latencies, failed checks are drawn as dots on the baseline
+type sparkline struct {
+	Width    int
+	Height   int
+	Points   string
+	Failures []point
+}
+
+type point struct {
+	X, Y float64
+}
+
+// severity orders cards so that sites needing attention go first
+var severity = map[sites.State]int{
+	sites.StateDown:     0,
+	sites.StateDegraded: 1,
+	sites.StateUnknown:  2,
+	sites.StateUp:       3,
+}
+
+func newPage(all []*sites.Site, h history.Service, uptime *history.UptimeCache, refresh int, now time.Time) page {
+	p := page{
+		Refresh:     refresh,
+		GeneratedAt: now.Format("15:04:05"),
+		Total:       len(all),
+		Sites:       make([]siteCard, 0, len(all)),
+	}
+
+	for _, site := range all {
+		card := newSiteCard(site, h, uptime, now)
+		switch card.State {
+		case sites.StateUp, sites.StateDegraded:
+			p.Up++
+		case sites.StateDown:
+			p.Down++
+		}
+		p.Sites = append(p.Sites, card)
+	}
+
+	sort.SliceStable(p.Sites, func(i, j int) bool {
+		a, b := p.Sites[i], p.Sites[j]
+		if severity[a.State] != severity[b.State] {
+			return severity[a.State] < severity[b.State]
+		}
+		return a.Name < b.Name
+	})
+
+	return p
+}
+
+func newSiteCard(site *sites.Site, h history.Service, uptime *history.UptimeCache, now time.Time) siteCard {
+	status := site.Status()
+	card := siteCard{
+		Name:       site.Name,
+		State:      status.State(),
+		Latency:    "—",
+		CheckedAt:  "never",
+		CheckedAgo: "never checked",
+		Uptime:     "—",
+		Error:      status.Error,
+	}
+	if status.CheckedAt.IsZero() {
+		return card
+	}
+
+	card.CheckedAt = status.CheckedAt.Format(time.RFC3339)
+	card.CheckedAgo = formatAgo(now.Sub(status.CheckedAt))
+	if status.Latency > 0 {
+		card.Latency = formatLatency(status.Latency)
+	}
+
+	// page is still useful without history
+	if all, err := uptime.Get(site.Name, now); err != nil {
+		card.Uptime = "n/a"
+	} else if u, ok := history.WindowUptime(all, uptimeWindow); ok {
+		card.Uptime = fmt.Sprintf("%.2f%%", u.Percent)
+	}
+
+	records, err := h.Range(site.Name, now.Add(-sparkWindow), now)
+	if err != nil {
+		return card
+	}
+	if len(records) > sparkPoints {
+		records = records[len(records)-sparkPoints:]
+	}
+	card.Sparkline = newSparkline(records)
+
+	return card
+}
+
+// newSparkline scales latencies of records to chart height, records are expected to be ordered by check time
+func newSparkline(records []history.Record) sparkline {
+	s := sparkline{Width: sparkWidth, Height: sparkHeight}
+	if len(records) == 0 {
+		return s
+	}
+
+	var max time.Duration
+	for _, rec := range records {
+		if rec.Alive && rec.Latency > max {
+			max = rec.Latency
+		}
+	}
+
+	baseline := float64(sparkHeight - sparkPadding)
+	step := 0.0
+	if len(records) > 1 {
+		step = float64(sparkWidth-2*sparkPadding) / float64(len(records)-1)
+	}
+
+	points := make([]string, 0, len(records))
+	for i, rec := range records {
+		x := float64(sparkPadding) + step*float64(i)
+		if len(records) == 1 {
+			x = float64(sparkWidth - sparkPadding)
+		}
+		if !rec.Alive {
+			s.Failures = append(s.Failures, point{X: x, Y: baseline})
+			continue
+		}
+
+		y := baseline
+		if max > 0 {
+			y -= float64(rec.Latency) / float64(max) * float64(sparkHeight-2*sparkPadding)
+		}
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	s.Points = strings.Join(points, " ")
+
+	return s
+}
+
+func formatLatency(d time.Duration) string {
+	if d < time.Millisecond {
+		return "<1 ms"
+	}
+	if d < 10*time.Second {
+		return fmt.Sprintf("%d ms", d/time.Millisecond)
+	}
+	return fmt.Sprintf("%.1f s", d.Seconds())
+}
+
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", d/time.Second)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", d/time.Minute)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dd ago", d/(24*time.Hour))
+	}
+}
@@ -0,0 +1,43 @@
+package board
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/history"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+func TestNewSparkline(t *testing.T) {
+	s := newSparkline(nil)
+	assert.Equal(t, "", s.Points)
+	assert.Empty(t, s.Failures)
+
+	records := []history.Record{
+		{Result: sites.Result{Alive: true, Latency: 100 * time.Millisecond}},
+		{Result: sites.Result{Alive: false}},
+		{Result: sites.Result{Alive: true, Latency: 50 * time.Millisecond}},
+	}
+	s = newSparkline(records)
+	// the slowest check touches the top, half as slow one is in the middle
+	assert.Equal(t, "2.0,2.0 118.0,14.0", s.Points)
+	assert.Equal(t, []point{{X: 60, Y: 26}}, s.Failures)
+
+	// single check is drawn on the right edge
+	s = newSparkline(records[:1])
+	assert.Equal(t, "118.0,2.0", s.Points)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "<1 ms", formatLatency(500*time.Microsecond))
+	assert.Equal(t, "120 ms", formatLatency(120*time.Millisecond))
+	assert.Equal(t, "12.5 s", formatLatency(12500*time.Millisecond))
+
+	assert.Equal(t, "just now", formatAgo(0))
+	assert.Equal(t, "15s ago", formatAgo(15*time.Second))
+	assert.Equal(t, "3m ago", formatAgo(3*time.Minute))
+	assert.Equal(t, "2h ago", formatAgo(2*time.Hour))
+	assert.Equal(t, "3d ago", formatAgo(72*time.Hour))
+}
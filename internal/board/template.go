@@ -0,0 +1,73 @@
+package board
+
+import "html/template"
+
+// pageTemplate is a self-contained status page, styles and charts are inlined so it works without external assets
+var pageTemplate = template.Must(template.New("board").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>{{if .Down}}({{.Down}} down) {{end}}Status board</title>
+<style>
+  * { box-sizing: border-box; }
+  body { margin: 0; padding: 24px; background: #111418; color: #e6e8eb;
+         font-family: -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; }
+  header { display: flex; align-items: baseline; justify-content: space-between; margin-bottom: 24px; }
+  h1 { margin: 0; font-size: 2em; font-weight: 600; }
+  .summary { font-size: 1.4em; color: #9aa3ad; }
+  .summary .down { color: #f25757; font-weight: 600; }
+  .grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(300px, 1fr)); gap: 16px; }
+  .card { background: #1b2027; border-radius: 8px; padding: 16px; border-left: 8px solid #5c6670; }
+  .card.up { border-left-color: #3ecf6e; }
+  .card.down { border-left-color: #f25757; background: #2a1a1d; }
+  .card.degraded { border-left-color: #f2b134; }
+  .name { font-size: 1.3em; font-weight: 600; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+  .state { float: right; font-size: .8em; font-weight: 700; text-transform: uppercase; padding: 2px 8px;
+           border-radius: 4px; background: #5c6670; color: #111418; }
+  .up .state { background: #3ecf6e; }
+  .down .state { background: #f25757; }
+  .degraded .state { background: #f2b134; }
+  .stats { display: flex; justify-content: space-between; margin: 12px 0 8px; color: #9aa3ad; }
+  .stats b { display: block; color: #e6e8eb; font-size: 1.2em; }
+  .error { color: #f25757; font-size: .9em; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+  svg { display: block; width: 100%; height: 32px; }
+  svg polyline { fill: none; stroke: #5fa8f5; stroke-width: 1.5; }
+  svg circle { fill: #f25757; }
+  .empty { color: #9aa3ad; font-size: 1.4em; }
+</style>
+</head>
+<body>
+<header>
+  <h1>Status board</h1>
+  <div class="summary">
+    {{.Up}}/{{.Total}} up{{if .Down}} · <span class="down">{{.Down}} down</span>{{end}} · updated {{.GeneratedAt}}
+  </div>
+</header>
+{{if .Sites}}
+<div class="grid">
+  {{range .Sites}}
+  <div class="card {{.State}}">
+    <div class="name" title="{{.Name}}"><span class="state">{{.State}}</span>{{.Name}}</div>
+    <div class="stats">
+      <div>latency<b>{{.Latency}}</b></div>
+      <div>uptime 24h<b>{{.Uptime}}</b></div>
+      <div title="{{.CheckedAt}}">checked<b>{{.CheckedAgo}}</b></div>
+    </div>
+    {{with .Sparkline}}
+    <svg viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="none" role="img" aria-label="recent latencies">
+      {{if .Points}}<polyline points="{{.Points}}"/>{{end}}
+      {{range .Failures}}<circle cx="{{.X}}" cy="{{.Y}}" r="1.5"/>{{end}}
+    </svg>
+    {{end}}
+    {{if .Error}}<div class="error" title="{{.Error}}">{{.Error}}</div>{{end}}
+  </div>
+  {{end}}
+</div>
+{{else}}
+<div class="empty">No sites configured</div>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,58 @@
+package history
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRange is used when query range start is omitted
+const defaultRange = 24 * time.Hour
+
+func RegisterHandlers(r *gin.Engine, service Service) {
+	res := resource{service}
+
+	r.GET("/history/:site", res.Range)
+}
+
+type resource struct {
+	service Service
+}
+
+// Range returns site check results within `from` and `to` RFC3339 query params.
+// Last 24 hours are returned by default
+func (r *resource) Range(c *gin.Context) {
+	to, err := parseTime(c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	from, err := parseTime(c.Query("from"), to.Add(-defaultRange))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := r.service.Range(c.Param("site"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, "unknown error")
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func parseTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("Invalid time %s, RFC3339 expected", value)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,65 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mullakhmetov/status-board/internal/sites"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	router, h := setupRouter()
+
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 3; i++ {
+		err := h.Append(Record{Site: "google.com", Result: sites.Result{CheckedAt: now.Add(-time.Duration(i) * time.Hour), Alive: true}})
+		assert.NoError(t, err)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/history/google.com", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var response []map[string]interface{}
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(response))
+	assert.Equal(t, "google.com", response[0]["site"])
+	assert.Equal(t, true, response[0]["alive"])
+
+	query := url.Values{}
+	query.Set("from", now.Add(-90*time.Minute).Format(time.RFC3339))
+	query.Set("to", now.Format(time.RFC3339))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/history/google.com?"+query.Encode(), nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(response))
+}
+
+func TestRange_InvalidTime(t *testing.T) {
+	router, _ := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/history/google.com?from=yesterday", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
+
+func setupRouter() (*gin.Engine, Service) {
+	r := gin.Default()
+	h := NewMemoryHistory(24 * time.Hour)
+	RegisterHandlers(r, h)
+	return r, h
+}
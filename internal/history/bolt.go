@@ -0,0 +1,117 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var historyBucket = []byte("history")
+
+// NewBoltHistory returns history storage in bolt db file at path keeping records for retention period
+func NewBoltHistory(path string, retention time.Duration) (Service, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open %s history db: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Failed to init %s history db: %v", path, err)
+	}
+
+	return &boltHistory{db: db, path: path, retention: retention}, nil
+}
+
+// boltHistory keeps records in per site buckets keyed by check time
+type boltHistory struct {
+	db        *bolt.DB
+	path      string
+	retention time.Duration
+}
+
+func (h *boltHistory) Append(rec Record) error {
+	data, err := json.Marshal(rec.Result)
+	if err != nil {
+		return err
+	}
+
+	// concurrent checks results are written in a single transaction
+	return h.db.Batch(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(historyBucket).CreateBucketIfNotExists([]byte(rec.Site))
+		if err != nil {
+			return err
+		}
+		if err := b.Put(timeKey(rec.CheckedAt), data); err != nil {
+			return err
+		}
+
+		// records are ordered by time so expired ones are at the beginning
+		expired := timeKey(rec.CheckedAt.Add(-h.retention))
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, expired) < 0; k, _ = c.Next() {
+			keys = append(keys, k)
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (h *boltHistory) Range(site string, from, to time.Time) ([]Record, error) {
+	records := []Record{}
+
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket).Bucket([]byte(site))
+		if b == nil {
+			return nil
+		}
+
+		max := timeKey(to)
+		c := b.Cursor()
+		for k, v := c.Seek(timeKey(from)); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() {
+			rec := Record{Site: site}
+			if err := json.Unmarshal(v, &rec.Result); err != nil {
+				return err
+			}
+			records = append(records, rec)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s site history: %v", site, err)
+	}
+
+	return records, nil
+}
+
+func (h *boltHistory) Close() {
+	if err := h.db.Close(); err != nil {
+		log.Printf("[ERROR] failed to close %s history db: %+v", h.path, err)
+	}
+}
+
+// timeKey encodes time as sortable bolt key
+func timeKey(t time.Time) []byte {
+	if t.Before(time.Unix(0, 0)) {
+		t = time.Unix(0, 0)
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
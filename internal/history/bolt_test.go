@@ -0,0 +1,21 @@
+package history
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltHistory(t *testing.T) {
+	path := "/tmp/test_history.db"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	h, err := NewBoltHistory(path, time.Hour)
+	assert.NoError(t, err)
+	defer h.Close()
+
+	testService(t, h)
+}
@@ -0,0 +1,25 @@
+// Package history stores sites check results and provides time range queries over them.
+
+package history
+
+import (
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// Record is a site check result
+type Record struct {
+	Site string `json:"site"`
+	sites.Result
+}
+
+// Service defines check results storage
+type Service interface {
+	// Append stores record and drops site records older than retention period
+	Append(rec Record) error
+	// Range returns site records checked within [from, to] ordered by check time
+	Range(site string, from, to time.Time) ([]Record, error)
+
+	Close()
+}
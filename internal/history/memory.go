@@ -0,0 +1,66 @@
+package history
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewMemoryHistory returns in-memory history storage keeping records for retention period
+func NewMemoryHistory(retention time.Duration) Service {
+	return &memoryHistory{
+		retention: retention,
+		records:   make(map[string][]Record),
+	}
+}
+
+type memoryHistory struct {
+	lock      sync.RWMutex
+	retention time.Duration
+	records   map[string][]Record
+}
+
+func (h *memoryHistory) Append(rec Record) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// results may come slightly out of order from concurrent checks,
+	// position is searched from the tail as it's almost always the last one
+	records := append(h.records[rec.Site], rec)
+	i := len(records) - 1
+	for ; i > 0 && records[i-1].CheckedAt.After(rec.CheckedAt); i-- {
+		records[i] = records[i-1]
+	}
+	records[i] = rec
+
+	expired := sort.Search(len(records), func(i int) bool {
+		return !records[i].CheckedAt.Before(rec.CheckedAt.Add(-h.retention))
+	})
+	h.records[rec.Site] = records[expired:]
+
+	return nil
+}
+
+func (h *memoryHistory) Range(site string, from, to time.Time) ([]Record, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	records := h.records[site]
+	start := sort.Search(len(records), func(i int) bool {
+		return !records[i].CheckedAt.Before(from)
+	})
+	end := sort.Search(len(records), func(i int) bool {
+		return records[i].CheckedAt.After(to)
+	})
+	if start >= end {
+		return []Record{}, nil
+	}
+
+	res := make([]Record, end-start)
+	copy(res, records[start:end])
+
+	return res, nil
+}
+
+// nothing to finalize
+func (h *memoryHistory) Close() {}
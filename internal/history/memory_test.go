@@ -0,0 +1,72 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryHistory(t *testing.T) {
+	testService(t, NewMemoryHistory(time.Hour))
+}
+
+// testService checks Service implementation contract
+func testService(t *testing.T, h Service) {
+	now := time.Now()
+
+	for i := 5; i >= 0; i-- {
+		err := h.Append(Record{
+			Site:   "google.com",
+			Result: sites.Result{CheckedAt: now.Add(-time.Duration(i) * time.Minute), Alive: i%2 == 0, StatusCode: 200},
+		})
+		assert.NoError(t, err)
+	}
+	err := h.Append(Record{Site: "vk.com", Result: sites.Result{CheckedAt: now, Error: "timeout"}})
+	assert.NoError(t, err)
+
+	records, err := h.Range("google.com", now.Add(-3*time.Minute), now.Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(records))
+	for i, rec := range records {
+		assert.Equal(t, "google.com", rec.Site)
+		assert.True(t, rec.CheckedAt.Equal(now.Add(-time.Duration(3-i)*time.Minute)))
+		assert.Equal(t, 200, rec.StatusCode)
+	}
+
+	records, err = h.Range("vk.com", now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "timeout", records[0].Error)
+
+	records, err = h.Range("unknown.site", now.Add(-time.Hour), now)
+	assert.NoError(t, err)
+	assert.NotNil(t, records)
+	assert.Equal(t, 0, len(records))
+
+	// records older than retention are dropped
+	err = h.Append(Record{Site: "google.com", Result: sites.Result{CheckedAt: now.Add(time.Hour - 2*time.Minute)}})
+	assert.NoError(t, err)
+
+	records, err = h.Range("google.com", now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(records))
+}
+
+func TestMemoryHistory_OutOfOrder(t *testing.T) {
+	h := NewMemoryHistory(time.Hour)
+	now := time.Now()
+
+	for _, offset := range []int{1, 3, 2, 5, 0, 4} {
+		err := h.Append(Record{Site: "google.com", Result: sites.Result{CheckedAt: now.Add(time.Duration(offset) * time.Second)}})
+		assert.NoError(t, err)
+	}
+
+	records, err := h.Range("google.com", now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(records))
+	for i, rec := range records {
+		assert.True(t, rec.CheckedAt.Equal(now.Add(time.Duration(i)*time.Second)))
+	}
+}
@@ -0,0 +1,122 @@
+package history
+
+import (
+	"sync"
+	"time"
+)
+
+// Window is a rolling period uptime is calculated over
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// UptimeWindows are periods site uptime is reported for
+var UptimeWindows = []Window{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// Uptime is a share of successful checks over a window
+type Uptime struct {
+	Window  string  `json:"window"`
+	Percent float64 `json:"percent"`
+	Checks  int     `json:"checks"`
+}
+
+// CalcUptime returns site uptime for every window ending at now.
+// Windows without any recorded check are omitted
+func CalcUptime(s Service, site string, now time.Time) ([]Uptime, error) {
+	longest := UptimeWindows[len(UptimeWindows)-1].Duration
+	records, err := s.Range(site, now.Add(-longest), now)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Uptime, 0, len(UptimeWindows))
+	for _, w := range UptimeWindows {
+		from := now.Add(-w.Duration)
+
+		var checks, alive int
+		// records are ordered by time, iterate from the latest one
+		for i := len(records) - 1; i >= 0 && !records[i].CheckedAt.Before(from); i-- {
+			checks++
+			if records[i].Alive {
+				alive++
+			}
+		}
+		if checks == 0 {
+			continue
+		}
+
+		res = append(res, Uptime{
+			Window:  w.Name,
+			Percent: float64(alive) / float64(checks) * 100,
+			Checks:  checks,
+		})
+	}
+
+	return res, nil
+}
+
+// UptimeCache keeps CalcUptime results for ttl so that frequent status requests
+// don't scan the longest window of history every time
+type UptimeCache struct {
+	service Service
+	ttl     time.Duration
+
+	lock      sync.Mutex
+	entries   map[string]uptimeEntry
+	lastSweep time.Time
+}
+
+type uptimeEntry struct {
+	uptime    []Uptime
+	expiresAt time.Time
+}
+
+func NewUptimeCache(s Service, ttl time.Duration) *UptimeCache {
+	return &UptimeCache{service: s, ttl: ttl, entries: make(map[string]uptimeEntry)}
+}
+
+// Get returns site uptime at most ttl old
+func (c *UptimeCache) Get(site string, now time.Time) ([]Uptime, error) {
+	c.lock.Lock()
+	entry, ok := c.entries[site]
+	c.lock.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.uptime, nil
+	}
+
+	uptime, err := CalcUptime(c.service, site, now)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[site] = uptimeEntry{uptime: uptime, expiresAt: now.Add(c.ttl)}
+	// entries of removed sites are dropped once expired
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for name, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, name)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	return uptime, nil
+}
+
+// WindowUptime returns uptime over window by name, false if there were no checks within it
+func WindowUptime(uptime []Uptime, window string) (Uptime, bool) {
+	for _, u := range uptime {
+		if u.Window == window {
+			return u, true
+		}
+	}
+	return Uptime{}, false
+}
@@ -0,0 +1,63 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcUptime(t *testing.T) {
+	h := NewMemoryHistory(31 * 24 * time.Hour)
+	now := time.Now()
+
+	// 1 failed check of 4 within the last hour
+	for i := 0; i < 4; i++ {
+		rec := Record{Site: "google.com", Result: sites.Result{CheckedAt: now.Add(-time.Duration(i) * 10 * time.Minute), Alive: i != 0}}
+		assert.NoError(t, h.Append(rec))
+	}
+	// 4 successful checks 2 days ago
+	for i := 0; i < 4; i++ {
+		rec := Record{Site: "google.com", Result: sites.Result{CheckedAt: now.Add(-48*time.Hour - time.Duration(i)*time.Minute), Alive: true}}
+		assert.NoError(t, h.Append(rec))
+	}
+
+	uptime, err := CalcUptime(h, "google.com", now)
+	assert.NoError(t, err)
+	assert.Equal(t, []Uptime{
+		{Window: "1h", Percent: 75, Checks: 4},
+		{Window: "24h", Percent: 75, Checks: 4},
+		{Window: "7d", Percent: 87.5, Checks: 8},
+		{Window: "30d", Percent: 87.5, Checks: 8},
+	}, uptime)
+
+	uptime, err = CalcUptime(h, "unknown.site", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(uptime))
+}
+
+func TestUptimeCache(t *testing.T) {
+	h := NewMemoryHistory(time.Hour)
+	now := time.Now()
+	assert.NoError(t, h.Append(Record{Site: "google.com", Result: sites.Result{CheckedAt: now, Alive: true}}))
+
+	c := NewUptimeCache(h, time.Minute)
+	uptime, err := c.Get("google.com", now)
+	assert.NoError(t, err)
+	u, ok := WindowUptime(uptime, "1h")
+	assert.True(t, ok)
+	assert.Equal(t, Uptime{Window: "1h", Percent: 100, Checks: 1}, u)
+	_, ok = WindowUptime(uptime, "1y")
+	assert.False(t, ok)
+
+	// cached result is returned until ttl passes
+	assert.NoError(t, h.Append(Record{Site: "google.com", Result: sites.Result{CheckedAt: now.Add(time.Second)}}))
+	uptime, err = c.Get("google.com", now.Add(30*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, uptime[0].Checks)
+
+	uptime, err = c.Get("google.com", now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, Uptime{Window: "1h", Percent: 50, Checks: 2}, uptime[0])
+}
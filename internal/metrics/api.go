@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,7 +22,7 @@ type resource struct {
 func (r *resource) Get(c *gin.Context) {
 	name := c.Param("site")
 
-	res, ok := r.metrics.Counters[name]
+	res, ok := r.metrics.Get(name)
 	if !ok {
 		c.JSON(http.StatusNotFound, "")
 		return
@@ -30,6 +31,15 @@ func (r *resource) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"name": res.Name(), "value": res.Count()})
 }
 
+// All returns counters as JSON map or all metrics in prometheus exposition format
+// if requested by Accept header or `format=prometheus` query param
 func (r *resource) All(c *gin.Context) {
+	if isExpositionRequest(c.Request) {
+		if err := r.metrics.WriteFamilies(c.Writer, c.Request); err != nil {
+			log.Printf("[ERROR] failed to write metrics: %+v", err)
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, r.metrics.Stats())
 }
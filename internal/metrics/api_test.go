@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -61,6 +62,38 @@ func TestMetrics(t *testing.T) {
 	assert.Equal(t, float64(1), response["bar checks"].(float64))
 }
 
+func TestMetrics_Prometheus(t *testing.T) {
+	router, registry := setupRouter()
+	registry.AddCounter("foo")
+	registry.Inc("foo")
+	registry.ObserveCheck("foo", true, 30*time.Millisecond)
+	registry.ObserveCheck("foo", false, 0)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE status_board_requests_total counter")
+	assert.Contains(t, body, `status_board_requests_total{site="foo"} 1`)
+	assert.Contains(t, body, `status_board_site_up{site="foo"} 0`)
+	assert.Contains(t, body, `status_board_checks_total{site="foo",outcome="success"} 1`)
+	assert.Contains(t, body, `status_board_checks_total{site="foo",outcome="failure"} 1`)
+	assert.Contains(t, body, "# TYPE status_board_check_duration_seconds histogram")
+	assert.Contains(t, body, `status_board_check_duration_seconds_bucket{site="foo",le="0.025"} 0`)
+	assert.Contains(t, body, `status_board_check_duration_seconds_bucket{site="foo",le="0.05"} 1`)
+	assert.Contains(t, body, `status_board_check_duration_seconds_count{site="foo"} 1`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/metrics?format=prometheus", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `status_board_site_up{site="foo"} 0`)
+}
+
 func TestMetric_NotFound(t *testing.T) {
 	router, registry := setupRouter()
 	registry.AddCounter("foo")
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+)
+
+// LatencyBuckets are upper bounds of check latency histogram buckets
+var LatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// CheckStats aggregates site checks outcomes
+type CheckStats struct {
+	Up        bool
+	Successes uint64
+	Failures  uint64
+
+	// LatencyBuckets holds cumulative counts of successful checks by LatencyBuckets bounds
+	LatencyBuckets []uint64
+	LatencyCount   uint64
+	LatencySum     time.Duration
+}
+
+func (s *CheckStats) observe(alive bool, latency time.Duration) {
+	s.Up = alive
+	if !alive {
+		s.Failures++
+		return
+	}
+
+	s.Successes++
+	s.LatencyCount++
+	s.LatencySum += latency
+
+	if s.LatencyBuckets == nil {
+		s.LatencyBuckets = make([]uint64, len(LatencyBuckets))
+	}
+	i := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return latency <= LatencyBuckets[i]
+	})
+	for ; i < len(LatencyBuckets); i++ {
+		s.LatencyBuckets[i]++
+	}
+}
+
+// ObserveCheck records site check outcome. Latency is accounted for successful checks only
+func (r *Registry) ObserveCheck(name string, alive bool, latency time.Duration) {
+	if r.dummy {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.checkStats(name).observe(alive, latency)
+}
+
+// SetUp sets site state, it may differ from the last check outcome when state changes are damped
+func (r *Registry) SetUp(name string, up bool) {
+	if r.dummy {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.checkStats(name).Up = up
+}
+
+// checkStats returns site checks stats creating them if needed, lock must be held
+func (r *Registry) checkStats(name string) *CheckStats {
+	if r.checks == nil {
+		r.checks = make(map[string]*CheckStats)
+	}
+	stats, ok := r.checks[name]
+	if !ok {
+		stats = &CheckStats{}
+		r.checks[name] = stats
+	}
+
+	return stats
+}
+
+// CheckStats returns copy of checks stats by site name
+func (r *Registry) CheckStats() map[string]CheckStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	res := make(map[string]CheckStats, len(r.checks))
+	for name, stats := range r.checks {
+		s := *stats
+		s.LatencyBuckets = append([]uint64(nil), stats.LatencyBuckets...)
+		res[name] = s
+	}
+
+	return res
+}
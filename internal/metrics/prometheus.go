@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const namespace = "status_board_"
+
+// Families returns registry contents as prometheus metric families
+func (r *Registry) Families() []*dto.MetricFamily {
+	requests := &dto.MetricFamily{
+		Name: proto.String(namespace + "requests_total"),
+		Help: proto.String("Status requests count by site."),
+		Type: dto.MetricType_COUNTER.Enum(),
+	}
+	up := &dto.MetricFamily{
+		Name: proto.String(namespace + "site_up"),
+		Help: proto.String("Whether the last site check succeeded."),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	checks := &dto.MetricFamily{
+		Name: proto.String(namespace + "checks_total"),
+		Help: proto.String("Site checks count by outcome."),
+		Type: dto.MetricType_COUNTER.Enum(),
+	}
+	latency := &dto.MetricFamily{
+		Name: proto.String(namespace + "check_duration_seconds"),
+		Help: proto.String("Successful site checks latency."),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+	}
+
+	r.lock.Lock()
+	for name, c := range r.Counters {
+		requests.Metric = append(requests.Metric, &dto.Metric{
+			Label:   siteLabels(name),
+			Counter: &dto.Counter{Value: proto.Float64(float64(c.Count()))},
+		})
+	}
+	r.lock.Unlock()
+
+	for name, s := range r.CheckStats() {
+		var value float64
+		if s.Up {
+			value = 1
+		}
+		up.Metric = append(up.Metric, &dto.Metric{
+			Label: siteLabels(name),
+			Gauge: &dto.Gauge{Value: proto.Float64(value)},
+		})
+
+		checks.Metric = append(checks.Metric,
+			&dto.Metric{
+				Label:   siteLabels(name, "outcome", "success"),
+				Counter: &dto.Counter{Value: proto.Float64(float64(s.Successes))},
+			},
+			&dto.Metric{
+				Label:   siteLabels(name, "outcome", "failure"),
+				Counter: &dto.Counter{Value: proto.Float64(float64(s.Failures))},
+			},
+		)
+
+		histogram := &dto.Histogram{
+			SampleCount: proto.Uint64(s.LatencyCount),
+			SampleSum:   proto.Float64(s.LatencySum.Seconds()),
+		}
+		for i, le := range LatencyBuckets {
+			var count uint64
+			if s.LatencyBuckets != nil {
+				count = s.LatencyBuckets[i]
+			}
+			histogram.Bucket = append(histogram.Bucket, &dto.Bucket{
+				UpperBound:      proto.Float64(le.Seconds()),
+				CumulativeCount: proto.Uint64(count),
+			})
+		}
+		latency.Metric = append(latency.Metric, &dto.Metric{
+			Label:     siteLabels(name),
+			Histogram: histogram,
+		})
+	}
+
+	families := []*dto.MetricFamily{requests, up, checks, latency}
+	for _, f := range families {
+		sortMetrics(f.Metric)
+	}
+
+	return families
+}
+
+// WriteFamilies writes registry contents in exposition format negotiated by request Accept header
+func (r *Registry) WriteFamilies(w http.ResponseWriter, req *http.Request) error {
+	format := expfmt.Negotiate(req.Header)
+	w.Header().Set("Content-Type", string(format))
+
+	enc := expfmt.NewEncoder(w, format)
+	for _, f := range r.Families() {
+		if len(f.Metric) == 0 {
+			continue
+		}
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isExpositionRequest reports whether client asks for prometheus exposition format instead of JSON
+func isExpositionRequest(req *http.Request) bool {
+	if req.URL.Query().Get("format") == "prometheus" {
+		return true
+	}
+
+	accept := req.Header.Get("Accept")
+	for _, t := range []string{"text/plain", "application/openmetrics-text", "application/vnd.google.protobuf"} {
+		if strings.Contains(accept, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func siteLabels(site string, pairs ...string) []*dto.LabelPair {
+	labels := []*dto.LabelPair{{Name: proto.String("site"), Value: proto.String(site)}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, &dto.LabelPair{Name: proto.String(pairs[i]), Value: proto.String(pairs[i+1])})
+	}
+	return labels
+}
+
+// sortMetrics orders metrics by labels to keep output stable
+func sortMetrics(metrics []*dto.Metric) {
+	key := func(m *dto.Metric) string {
+		parts := make([]string, 0, len(m.Label))
+		for _, l := range m.Label {
+			parts = append(parts, l.GetValue())
+		}
+		return strings.Join(parts, "\x00")
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return key(metrics[i]) < key(metrics[j])
+	})
+}
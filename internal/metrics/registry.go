@@ -20,13 +20,14 @@ func NewRegistry(dummy bool) *Registry {
 	}
 }
 
-// Registry stores Counters set
+// Registry stores Counters set and site checks stats
 type Registry struct {
 	lock            sync.Mutex
 	InitCounterFunc func(string) Counter
 	Counters        map[string]Counter
 
-	dummy bool
+	checks map[string]*CheckStats
+	dummy  bool
 }
 
 // AddCounter inits new Counter by name and adds it no Registry
@@ -38,8 +39,60 @@ func (r *Registry) AddCounter(name string) {
 	return
 }
 
+// RemoveCounter drops Counter by name from Registry
+func (r *Registry) RemoveCounter(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.Counters, name)
+}
+
+// Sync adds Counters for new names and removes Counters and checks stats of names not listed.
+// Existing Counters keep their values
+func (r *Registry) Sync(names []string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	actual := make(map[string]bool, len(names))
+	for _, name := range names {
+		actual[name] = true
+		if _, ok := r.Counters[name]; !ok {
+			r.Counters[name] = r.InitCounterFunc(name)
+		}
+	}
+
+	for name := range r.Counters {
+		if !actual[name] {
+			delete(r.Counters, name)
+		}
+	}
+	for name := range r.checks {
+		if !actual[name] {
+			delete(r.checks, name)
+		}
+	}
+}
+
+// Get returns Counter by name
+func (r *Registry) Get(name string) (Counter, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	c, ok := r.Counters[name]
+	return c, ok
+}
+
+// Inc increments Counter by name if it's registered
+func (r *Registry) Inc(name string) {
+	if c, ok := r.Get(name); ok {
+		c.Inc()
+	}
+}
+
 // Stats returns all counters `name: values` map
 func (r *Registry) Stats() map[string]int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	m := make(map[string]int64)
 
 	for _, v := range r.Counters {
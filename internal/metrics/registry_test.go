@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -33,3 +34,47 @@ func TestRegistry(t *testing.T) {
 
 	assert.Equal(t, expectedStats, r.Stats())
 }
+
+func TestRegistry_Sync(t *testing.T) {
+	r := NewRegistry(false)
+	r.AddCounter("foo")
+	r.AddCounter("bar")
+	r.Inc("foo")
+
+	r.ObserveCheck("bar", true, time.Second)
+	r.ObserveCheck("foo", true, time.Second)
+
+	r.Sync([]string{"foo", "buz"})
+
+	assert.Equal(t, map[string]int64{"foo checks": 1, "buz checks": 0}, r.Stats())
+
+	_, ok := r.Get("bar")
+	assert.False(t, ok)
+	// unknown counters are ignored
+	r.Inc("bar")
+
+	checks := r.CheckStats()
+	assert.Equal(t, 1, len(checks))
+	assert.True(t, checks["foo"].Up)
+}
+
+func TestRegistry_ObserveCheck(t *testing.T) {
+	r := NewRegistry(false)
+	r.ObserveCheck("foo", true, 20*time.Millisecond)
+	r.ObserveCheck("foo", true, 3*time.Second)
+	r.ObserveCheck("foo", false, 0)
+
+	stats := r.CheckStats()["foo"]
+	assert.False(t, stats.Up)
+	r.SetUp("foo", true)
+	assert.True(t, r.CheckStats()["foo"].Up)
+	assert.Equal(t, uint64(2), stats.Successes)
+	assert.Equal(t, uint64(1), stats.Failures)
+	assert.Equal(t, uint64(2), stats.LatencyCount)
+	assert.Equal(t, 3020*time.Millisecond, stats.LatencySum)
+	assert.Equal(t, []uint64{0, 1, 1, 1, 1, 1, 1, 1, 2, 2}, stats.LatencyBuckets)
+
+	dummy := NewRegistry(true)
+	dummy.ObserveCheck("foo", true, time.Second)
+	assert.Equal(t, 0, len(dummy.CheckStats()))
+}
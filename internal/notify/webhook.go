@@ -0,0 +1,161 @@
+// Package notify delivers sites state changes to external receivers.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// Payload is a JSON body posted to webhook urls on site state change
+type Payload struct {
+	Site      string        `json:"site"`
+	OldState  sites.State   `json:"old_state"`
+	NewState  sites.State   `json:"new_state"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// WebhookOpts configures webhook delivery
+type WebhookOpts struct {
+	// Timeout limits a single delivery attempt
+	Timeout time.Duration
+	// Retries is a number of extra attempts for failed delivery
+	Retries int
+	// Backoff is a delay before the first retry, doubled on every next one
+	Backoff time.Duration
+	// QueueSize limits number of pending notifications, newer ones are dropped on overflow
+	QueueSize int
+}
+
+// NewWebhook returns asker listener posting site state changes to urls
+func NewWebhook(urls []string, opts WebhookOpts) *Webhook {
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = 100
+	}
+
+	return &Webhook{
+		urls:       urls,
+		opts:       opts,
+		httpClient: http.Client{Timeout: opts.Timeout},
+		queue:      make(chan Payload, opts.QueueSize),
+	}
+}
+
+// Webhook delivers notifications asynchronously so checks are never blocked by slow receivers
+type Webhook struct {
+	urls       []string
+	opts       WebhookOpts
+	httpClient http.Client
+	queue      chan Payload
+	wg         sync.WaitGroup
+}
+
+// OnEvent enqueues notification on site state change
+func (w *Webhook) OnEvent(ev asker.Event) {
+	if !ev.Changed() || len(w.urls) == 0 {
+		return
+	}
+
+	p := Payload{
+		Site:      ev.Site,
+		OldState:  ev.Previous,
+		NewState:  ev.Current,
+		Latency:   ev.Result.Latency,
+		Error:     ev.Result.Error,
+		Timestamp: ev.Result.CheckedAt,
+	}
+
+	select {
+	case w.queue <- p:
+	default:
+		log.Printf("[WARN] webhook queue is full, %s site %s notification dropped", p.Site, p.NewState)
+	}
+}
+
+// Run starts delivery loop until ctx is done
+func (w *Webhook) Run(ctx context.Context) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case p := <-w.queue:
+				for _, url := range w.urls {
+					if err := w.deliver(ctx, url, p); err != nil {
+						log.Printf("[ERROR] failed to notify %s about %s site: %+v", url, p.Site, err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Close waits for delivery loop termination
+func (w *Webhook) Close() {
+	w.wg.Wait()
+}
+
+// deliver posts payload to url retrying with exponential backoff
+func (w *Webhook) deliver(ctx context.Context, url string, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, url, body)
+		if err == nil || attempt >= w.opts.Retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+func (w *Webhook) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/sites"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	var attempts int32
+	received := make(chan Payload, 10)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		// the first attempt fails
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+
+		var p Payload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		received <- p
+	}))
+	defer ts.Close()
+
+	w := NewWebhook([]string{ts.URL}, WebhookOpts{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	w.Run(ctx)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	// not a state change
+	w.OnEvent(asker.Event{Site: "google.com", Previous: sites.StateUp, Current: sites.StateUp})
+	w.OnEvent(asker.Event{Site: "google.com", Previous: sites.StateUnknown, Current: sites.StateUp})
+	// state change
+	w.OnEvent(asker.Event{
+		Site:     "google.com",
+		Previous: sites.StateUp,
+		Current:  sites.StateDown,
+		Result:   sites.Result{CheckedAt: now, Latency: time.Second, Error: "timeout"},
+	})
+
+	select {
+	case p := <-received:
+		assert.Equal(t, Payload{
+			Site:      "google.com",
+			OldState:  sites.StateUp,
+			NewState:  sites.StateDown,
+			Latency:   time.Second,
+			Error:     "timeout",
+			Timestamp: now,
+		}, p)
+	case <-time.After(2 * time.Second):
+		t.Fatal("notification wasn't delivered")
+	}
+
+	cancel()
+	w.Close()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	assert.Equal(t, 0, len(received))
+}
+
+func TestWebhook_RetriesExhausted(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(500)
+	}))
+	defer ts.Close()
+
+	w := NewWebhook([]string{ts.URL}, WebhookOpts{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond})
+
+	err := w.deliver(context.Background(), ts.URL, Payload{Site: "google.com"})
+	assert.EqualError(t, err, "unexpected status 500")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestWebhook_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	w := NewWebhook([]string{ts.URL}, WebhookOpts{Timeout: 10 * time.Millisecond})
+
+	err := w.deliver(context.Background(), ts.URL, Payload{Site: "google.com"})
+	assert.Error(t, err)
+}
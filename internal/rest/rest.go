@@ -9,18 +9,26 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/badge"
+	"github.com/mullakhmetov/status-board/internal/board"
+	"github.com/mullakhmetov/status-board/internal/history"
 	"github.com/mullakhmetov/status-board/internal/metrics"
+	"github.com/mullakhmetov/status-board/internal/notify"
 	"github.com/mullakhmetov/status-board/internal/sites"
+	"github.com/mullakhmetov/status-board/internal/stream"
 )
 
 type services struct {
-	sites sites.Service
-	asker asker.Service
+	sites   sites.Service
+	asker   asker.Service
+	history history.Service
 }
 
 type server struct {
 	srv *http.Server
 	*services
+	webhook    *notify.Webhook
+	broker     *stream.Broker
 	terminated chan struct{}
 }
 
@@ -30,19 +38,83 @@ type ServerOpts struct {
 	ChecksRate   time.Duration
 	StoreMetrics bool
 	SitesPath    string
+	// DbPath enables sites and status persistence, sites file is used as initial data then
+	DbPath string
+	// HistoryPath enables check results persistence, results are kept in memory otherwise
+	HistoryPath      string
+	HistoryRetention time.Duration
+	LatencyWindow    time.Duration
+	// Concurrency and HostConcurrency bound simultaneous checks, unlimited if zero
+	Concurrency     int
+	HostConcurrency int
+	SpreadChecks    bool
+	// Jitter is a fraction of check interval randomly added or subtracted
+	Jitter float64
+	// Retries and RetryBackoff configure failed requests retries within a check
+	Retries      int
+	RetryBackoff time.Duration
+	// Hysteresis is a number of consecutive results required to change site state
+	Hysteresis sites.Hysteresis
+	// CertWarning marks sites with certificates expiring within as degraded, disabled if zero
+	CertWarning time.Duration
+	// Webhooks are notified on sites state changes
+	Webhooks    []string
+	WebhookOpts notify.WebhookOpts
 }
 
 func NewServer(opts ServerOpts) (*server, error) {
 	router := gin.Default()
 
-	sitesServices := sites.NewFileSitesService(opts.SitesPath)
-	sitesServices.Warmup()
+	var err error
+	var sitesServices sites.Service
+	if opts.DbPath != "" {
+		sitesServices = sites.NewBoltSitesService(opts.DbPath, opts.SitesPath)
+	} else {
+		sitesServices = sites.NewFileSitesService(opts.SitesPath)
+	}
+	if err = sitesServices.Warmup(); err != nil {
+		return nil, err
+	}
+	sites.RegisterHandlers(router, sitesServices)
 
 	metricsRegistry := metrics.NewRegistry(!opts.StoreMetrics)
 	metrics.RegisterHandlers(router, metricsRegistry)
 
-	askerService := asker.NewHttpAsker(sitesServices, metricsRegistry, opts.Timeout, opts.ChecksRate)
+	var historyService history.Service
+	if opts.HistoryPath != "" {
+		historyService, err = history.NewBoltHistory(opts.HistoryPath, opts.HistoryRetention)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		historyService = history.NewMemoryHistory(opts.HistoryRetention)
+	}
+	history.RegisterHandlers(router, historyService)
+	uptimeCache := history.NewUptimeCache(historyService, time.Minute)
+	board.RegisterHandlers(router, sitesServices, historyService, uptimeCache)
+
+	webhook := notify.NewWebhook(opts.Webhooks, opts.WebhookOpts)
+	broker := stream.NewBroker(stream.BrokerOpts{})
+	stream.RegisterHandlers(router, broker)
+
+	askerService := asker.NewHttpAsker(sitesServices, metricsRegistry, asker.Opts{
+		Timeout:         opts.Timeout,
+		Rate:            opts.ChecksRate,
+		History:         historyService,
+		UptimeCache:     uptimeCache,
+		LatencyWindow:   opts.LatencyWindow,
+		Listeners:       []asker.Listener{webhook, broker},
+		Concurrency:     opts.Concurrency,
+		HostConcurrency: opts.HostConcurrency,
+		Spread:          opts.SpreadChecks,
+		Jitter:          opts.Jitter,
+		Retries:         opts.Retries,
+		RetryBackoff:    opts.RetryBackoff,
+		Hysteresis:      opts.Hysteresis,
+		CertWarning:     opts.CertWarning,
+	})
 	asker.RegisterHandlers(router, askerService)
+	badge.RegisterHandlers(router, askerService)
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", opts.Port),
@@ -52,16 +124,25 @@ func NewServer(opts ServerOpts) (*server, error) {
 	s := &server{
 		srv: srv,
 		services: &services{
-			sites: sitesServices,
-			asker: askerService,
+			sites:   sitesServices,
+			asker:   askerService,
+			history: historyService,
 		},
+		webhook:    webhook,
+		broker:     broker,
 		terminated: make(chan struct{}),
 	}
 	return s, nil
 }
 
 func (s *server) Run(ctx context.Context) error {
-	// start asker loop
+	// reload sites on source changes
+	if err := s.services.sites.Watch(ctx); err != nil {
+		log.Printf("[ERROR] sites hot reload disabled: %+v", err)
+	}
+
+	// start notifications delivery and asker loop
+	s.webhook.Run(ctx)
 	s.services.asker.Run(ctx)
 
 	go func() {
@@ -69,7 +150,10 @@ func (s *server) Run(ctx context.Context) error {
 		<-ctx.Done()
 		// Close services
 		s.services.asker.Close()
+		s.webhook.Close()
+		s.broker.Close()
 		s.services.sites.Close()
+		s.services.history.Close()
 
 		s.srv.Shutdown(ctx)
 		log.Print("[INFO] server was shut down")
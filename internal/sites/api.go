@@ -0,0 +1,121 @@
+package sites
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHandlers(r *gin.Engine, service Service) {
+	res := resource{service}
+
+	r.GET("/sites", res.List)
+	r.POST("/sites", res.Create)
+	r.GET("/sites/:name", res.Get)
+	r.PUT("/sites/:name", res.Update)
+	r.DELETE("/sites/:name", res.Delete)
+}
+
+// siteView is a site definition along with it's current status
+type siteView struct {
+	Definition
+	Alive     bool          `json:"alive"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Error     string        `json:"error,omitempty"`
+
+	ConsecutiveFailures int `json:"consecutive_failures"`
+}
+
+func newSiteView(site *Site) siteView {
+	status := site.Status()
+	return siteView{
+		Definition: site.Definition(),
+		Alive:      status.Alive,
+		Latency:    status.Latency,
+		CheckedAt:  status.CheckedAt,
+		Error:      status.Error,
+
+		ConsecutiveFailures: status.ConsecutiveFailures,
+	}
+}
+
+type resource struct {
+	service Service
+}
+
+func (r *resource) List(c *gin.Context) {
+	sites := r.service.GetAll()
+
+	res := make([]siteView, 0, len(sites))
+	for _, site := range sites {
+		res = append(res, newSiteView(site))
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (r *resource) Get(c *gin.Context) {
+	site, err := r.service.Get(c.Param("name"))
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSiteView(site))
+}
+
+func (r *resource) Create(c *gin.Context) {
+	var def Definition
+	if err := c.ShouldBindJSON(&def); err != nil {
+		r.handleError(c, &ValidationError{err})
+		return
+	}
+
+	site, err := r.service.Add(def)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newSiteView(site))
+}
+
+func (r *resource) Update(c *gin.Context) {
+	var def Definition
+	if err := c.ShouldBindJSON(&def); err != nil {
+		r.handleError(c, &ValidationError{err})
+		return
+	}
+
+	site, err := r.service.Update(c.Param("name"), def)
+	if err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSiteView(site))
+}
+
+func (r *resource) Delete(c *gin.Context) {
+	if err := r.service.Delete(c.Param("name")); err != nil {
+		r.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (r *resource) handleError(c *gin.Context, err error) {
+	switch v := err.(type) {
+	case *NotFoundError:
+		c.JSON(http.StatusNotFound, v.Error())
+	case *AlreadyExistsError:
+		c.JSON(http.StatusConflict, v.Error())
+	case *ValidationError:
+		c.JSON(http.StatusBadRequest, v.Error())
+	default:
+		c.JSON(http.StatusInternalServerError, "unknown error")
+	}
+}
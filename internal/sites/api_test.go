@@ -0,0 +1,117 @@
+package sites
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	router, ms := setupRouter()
+
+	site, err := newSite(Definition{Url: "google.com"})
+	assert.NoError(t, err)
+	site.MarkAvailable(1)
+
+	ms.On("GetAll").Return([]*Site{site})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/sites", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var response []map[string]interface{}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(response))
+	assert.Equal(t, "google.com", response[0]["name"])
+	assert.Equal(t, true, response[0]["alive"])
+	ms.AssertExpectations(t)
+}
+
+func TestGet(t *testing.T) {
+	router, ms := setupRouter()
+
+	ms.On("Get", "unknown").Return(nil, &NotFoundError{"unknown"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/sites/unknown", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 404, w.Code)
+	ms.AssertExpectations(t)
+}
+
+func TestCreate(t *testing.T) {
+	router, ms := setupRouter()
+
+	def := Definition{Name: "api", Url: "https://api.example.com", Method: "HEAD"}
+	site, err := newSite(def)
+	assert.NoError(t, err)
+
+	ms.On("Add", def).Return(site, nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/sites", jsonBody(t, def))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 201, w.Code)
+	ms.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/sites", bytes.NewBufferString("not a json"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestCreate_Conflict(t *testing.T) {
+	router, ms := setupRouter()
+
+	def := Definition{Url: "google.com"}
+	ms.On("Add", def).Return(nil, &AlreadyExistsError{"google.com"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/sites", jsonBody(t, def))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 409, w.Code)
+	ms.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	router, ms := setupRouter()
+
+	def := Definition{Url: "https://google.com"}
+	site, err := newSite(Definition{Name: "google.com", Url: "https://google.com"})
+	assert.NoError(t, err)
+
+	ms.On("Update", "google.com", def).Return(site, nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/sites/google.com", jsonBody(t, def))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	ms.AssertExpectations(t)
+}
+
+func TestDelete(t *testing.T) {
+	router, ms := setupRouter()
+
+	ms.On("Delete", "google.com").Return(nil)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/sites/google.com", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+	ms.AssertExpectations(t)
+}
+
+func jsonBody(t *testing.T, v interface{}) *bytes.Buffer {
+	buf := bytes.NewBuffer(nil)
+	err := json.NewEncoder(buf).Encode(v)
+	assert.NoError(t, err)
+	return buf
+}
+
+func setupRouter() (*gin.Engine, *MockedService) {
+	r := gin.Default()
+	ms := new(MockedService)
+	RegisterHandlers(r, ms)
+	return r, ms
+}
@@ -0,0 +1,248 @@
+package sites
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expectation defines check success criteria.
+// Status items are single codes ("200"), ranges ("200-299") or classes ("2xx").
+// JSON maps dotted field paths, e.g. "data.items.0.state", to expected scalar values.
+// Answers are dns records every one of which must be resolved, MaxLatency is a Go duration string
+type Expectation struct {
+	Status       []string               `yaml:"status" json:"status,omitempty"`
+	BodyContains string                 `yaml:"body_contains" json:"body_contains,omitempty"`
+	BodyRegex    string                 `yaml:"body_regex" json:"body_regex,omitempty"`
+	JSON         map[string]interface{} `yaml:"json" json:"json,omitempty"`
+	MaxBodySize  int64                  `yaml:"max_body_size" json:"max_body_size,omitempty"`
+	Answers      []string               `yaml:"answers" json:"answers,omitempty"`
+	MaxLatency   string                 `yaml:"max_latency" json:"max_latency,omitempty"`
+}
+
+// Assertions verify check response against compiled Expectation
+type Assertions struct {
+	statuses []statusRange
+	contains []byte
+	regex    *regexp.Regexp
+	json     map[string]interface{}
+	answers  []string
+
+	// MaxLatency fails successful checks slower than it, latency isn't checked if zero
+	MaxLatency time.Duration
+	// MaxBodySize limits response body read, body isn't limited if zero
+	MaxBodySize int64
+}
+
+type statusRange struct {
+	from, to int
+}
+
+// NewAssertions compiles expectation
+func NewAssertions(exp Expectation) (*Assertions, error) {
+	a := &Assertions{
+		contains:    []byte(exp.BodyContains),
+		MaxBodySize: exp.MaxBodySize,
+	}
+
+	for _, s := range exp.Status {
+		r, err := parseStatusRange(s)
+		if err != nil {
+			return nil, err
+		}
+		a.statuses = append(a.statuses, r)
+	}
+
+	if exp.BodyRegex != "" {
+		regex, err := regexp.Compile(exp.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid body regex: %v", err)
+		}
+		a.regex = regex
+	}
+
+	if len(exp.JSON) > 0 {
+		a.json = make(map[string]interface{}, len(exp.JSON))
+		for path, value := range exp.JSON {
+			switch value.(type) {
+			case nil, bool, string, int, int64, float64:
+				a.json[path] = value
+			default:
+				return nil, fmt.Errorf("Invalid %s json field expectation: scalar value expected", path)
+			}
+		}
+	}
+
+	if a.MaxBodySize < 0 {
+		return nil, fmt.Errorf("Invalid max body size: %d", a.MaxBodySize)
+	}
+
+	for _, answer := range exp.Answers {
+		a.answers = append(a.answers, normalizeAnswer(answer))
+	}
+
+	if exp.MaxLatency != "" {
+		var err error
+		if a.MaxLatency, err = time.ParseDuration(exp.MaxLatency); err != nil {
+			return nil, fmt.Errorf("Invalid max latency: %v", err)
+		}
+	}
+
+	return a, nil
+}
+
+// defaultStatuses are accepted if no statuses defined, server errors mean resource isn't available
+var defaultStatuses = []statusRange{{100, 499}}
+
+// CheckStatus returns error if response code isn't expected. Any code but 5xx is accepted if no statuses defined
+func (a *Assertions) CheckStatus(code int) error {
+	statuses := defaultStatuses
+	if a != nil && len(a.statuses) > 0 {
+		statuses = a.statuses
+	}
+	for _, r := range statuses {
+		if code >= r.from && code <= r.to {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected status %d", code)
+}
+
+// CheckLatency returns error if latency exceeds MaxLatency
+func (a *Assertions) CheckLatency(latency time.Duration) error {
+	if a == nil || a.MaxLatency == 0 || latency <= a.MaxLatency {
+		return nil
+	}
+	return fmt.Errorf("latency %s exceeds %s", latency, a.MaxLatency)
+}
+
+// CheckAnswers returns error if any of expected dns answers isn't resolved
+func (a *Assertions) CheckAnswers(answers []string) error {
+	if a == nil || len(a.answers) == 0 {
+		return nil
+	}
+
+	resolved := make(map[string]bool, len(answers))
+	for _, answer := range answers {
+		resolved[normalizeAnswer(answer)] = true
+	}
+	for _, expected := range a.answers {
+		if !resolved[expected] {
+			return fmt.Errorf("answer %s not resolved", expected)
+		}
+	}
+
+	return nil
+}
+
+// normalizeAnswer makes dns names comparable regardless of case and trailing dot
+func normalizeAnswer(answer string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(answer)), ".")
+}
+
+// ChecksBody reports whether response body should be verified
+func (a *Assertions) ChecksBody() bool {
+	return a != nil && (len(a.contains) > 0 || a.regex != nil || len(a.json) > 0)
+}
+
+// CheckBody returns first failed body assertion
+func (a *Assertions) CheckBody(body []byte) error {
+	if !a.ChecksBody() {
+		return nil
+	}
+
+	if len(a.contains) > 0 && !bytes.Contains(body, a.contains) {
+		return fmt.Errorf("body doesn't contain %q", a.contains)
+	}
+
+	if a.regex != nil && !a.regex.Match(body) {
+		return fmt.Errorf("body doesn't match %q", a.regex.String())
+	}
+
+	if len(a.json) == 0 {
+		return nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body isn't valid json: %v", err)
+	}
+	for path, expected := range a.json {
+		actual, ok := lookupJSON(doc, path)
+		if !ok {
+			return fmt.Errorf("json field %s not found", path)
+		}
+		if !equalJSON(expected, actual) {
+			return fmt.Errorf("json field %s is %v, %v expected", path, actual, expected)
+		}
+	}
+
+	return nil
+}
+
+func parseStatusRange(s string) (statusRange, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("Invalid status %q", s)
+
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") {
+		class, err := strconv.Atoi(s[:1])
+		if err != nil {
+			return statusRange{}, invalid
+		}
+		return statusRange{class * 100, class*100 + 99}, nil
+	}
+
+	bounds := strings.SplitN(s, "-", 2)
+	from, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return statusRange{}, invalid
+	}
+	to := from
+	if len(bounds) == 2 {
+		if to, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil || to < from {
+			return statusRange{}, invalid
+		}
+	}
+
+	return statusRange{from, to}, nil
+}
+
+// lookupJSON returns value by dotted path, numeric path parts index arrays
+func lookupJSON(doc interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			val, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			doc = val
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// equalJSON compares values by their json representation, so 1 from yaml equals 1.0 from json
+func equalJSON(expected, actual interface{}) bool {
+	e, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	a, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(e, a)
+}
@@ -0,0 +1,89 @@
+package sites
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAssertions(t *testing.T) {
+	a, err := NewAssertions(Expectation{Status: []string{"200", "301-302", "5xx"}})
+	assert.NoError(t, err)
+
+	for _, code := range []int{200, 301, 302, 500, 599} {
+		assert.NoError(t, a.CheckStatus(code))
+	}
+	for _, code := range []int{201, 303, 404} {
+		assert.EqualError(t, a.CheckStatus(code), "unexpected status "+strconv.Itoa(code))
+	}
+	assert.False(t, a.ChecksBody())
+
+	// server errors fail by default
+	a, err = NewAssertions(Expectation{BodyContains: "ok"})
+	assert.NoError(t, err)
+	for _, code := range []int{200, 302, 404} {
+		assert.NoError(t, a.CheckStatus(code))
+	}
+	assert.EqualError(t, a.CheckStatus(503), "unexpected status 503")
+
+	invalid := []Expectation{
+		{Status: []string{"ok"}},
+		{Status: []string{"302-301"}},
+		{Status: []string{"yxx"}},
+		{BodyRegex: "("},
+		{JSON: map[string]interface{}{"items": []interface{}{1}}},
+		{MaxBodySize: -1},
+		{MaxLatency: "fast"},
+	}
+	for _, exp := range invalid {
+		_, err := NewAssertions(exp)
+		assert.Error(t, err)
+	}
+}
+
+func TestAssertions_DNS(t *testing.T) {
+	a, err := NewAssertions(Expectation{Answers: []string{"10.0.0.1", "MX.example.com."}, MaxLatency: "100ms"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.CheckAnswers([]string{"mx.example.com", "10.0.0.2", "10.0.0.1"}))
+	assert.EqualError(t, a.CheckAnswers([]string{"10.0.0.1"}), "answer mx.example.com not resolved")
+
+	assert.NoError(t, a.CheckLatency(100*time.Millisecond))
+	assert.EqualError(t, a.CheckLatency(time.Second), "latency 1s exceeds 100ms")
+
+	var none *Assertions
+	assert.NoError(t, none.CheckAnswers(nil))
+	assert.NoError(t, none.CheckLatency(time.Hour))
+}
+
+func TestFileSites_WarmUp_Expect(t *testing.T) {
+	content := `
+- url: https://api.example.com/health
+  expected_status: [204]
+  expect:
+    status: ["2xx"]
+    body_regex: "^OK"
+    json:
+      status: ok
+      replicas: 3
+    max_body_size: 4096
+`
+	path, teardown := prepFileContent(t, "/tmp/test_sites_expect.yaml", content)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+	assert.NoError(t, s.Warmup())
+
+	a := s.GetAll()[0].Assertions
+	assert.NoError(t, a.CheckStatus(204))
+	assert.Equal(t, int64(4096), a.MaxBodySize)
+	assert.True(t, a.ChecksBody())
+	assert.EqualError(t, a.CheckBody([]byte(`{"status": "ok", "replicas": 3}`)), `body doesn't match "^OK"`)
+
+	a.regex = nil
+	assert.NoError(t, a.CheckBody([]byte(`{"status": "ok", "replicas": 3.0}`)))
+	assert.EqualError(t, a.CheckBody([]byte(`{"status": "ok", "replicas": 2}`)), "json field replicas is 2, 3 expected")
+	assert.Error(t, a.CheckBody([]byte(`not json`)))
+}
@@ -0,0 +1,277 @@
+package sites
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	sitesBucket = []byte("sites")
+	metaBucket  = []byte("meta")
+	// seededKey marks db sites file was imported to, so it isn't imported again once all sites are deleted
+	seededKey = []byte("seeded")
+)
+
+// NewBoltSitesService returns sites service persisted in bolt db file at dbPath.
+// Sites from seedPath file are imported on first start only
+func NewBoltSitesService(dbPath, seedPath string) Service {
+	return &boltSites{dbPath: dbPath, seedPath: seedPath}
+}
+
+type boltSites struct {
+	siteList
+
+	db       *bolt.DB
+	dbPath   string
+	seedPath string
+}
+
+// siteRecord is a site representation stored in db
+type siteRecord struct {
+	Definition Definition    `json:"definition"`
+	Alive      bool          `json:"alive"`
+	Latency    time.Duration `json:"latency"`
+	CheckedAt  time.Time     `json:"checked_at"`
+	ChangedAt  time.Time     `json:"changed_at"`
+	Error      string        `json:"error,omitempty"`
+	// consecutive results are kept for state changes hysteresis
+	ConsecutiveFailures  int `json:"consecutive_failures,omitempty"`
+	ConsecutiveSuccesses int `json:"consecutive_successes,omitempty"`
+}
+
+func (s *boltSites) Warmup() error {
+	db, err := bolt.Open(s.dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("Failed to open %s sites db: %v", s.dbPath, err)
+	}
+	s.db = db
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(metaBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(sitesBucket)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to init %s sites db: %v", s.dbPath, err)
+	}
+
+	sites, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if s.seedPath != "" && !s.seeded() {
+		// db filled before seeded marker was introduced is considered seeded
+		if len(sites) > 0 {
+			err = s.db.Update(markSeeded)
+		} else {
+			sites, err = s.seed()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to seed %s sites db: %v", s.dbPath, err)
+		}
+	}
+
+	s.merge(sites)
+	log.Printf("[INFO] loaded %d sites from %s", len(sites), s.dbPath)
+
+	return nil
+}
+
+// Reload re-reads sites from db
+func (s *boltSites) Reload() error {
+	sites, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.merge(sites)
+
+	return nil
+}
+
+// Watch doesn't watch anything as db is changed through the service only.
+// Sites file is imported on the first start, later changes to it are ignored
+func (s *boltSites) Watch(ctx context.Context) error {
+	if s.seedPath != "" {
+		log.Printf("[WARN] %s sites file changes are ignored, sites are kept in %s db and managed through API", s.seedPath, s.dbPath)
+	}
+	return nil
+}
+
+func (s *boltSites) Add(def Definition) (*Site, error) {
+	site, err := validateSite(def)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.add(site, func() error {
+		return s.put(site)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return site, nil
+}
+
+// Update replaces definition of site by name. Site status is reset
+func (s *boltSites) Update(name string, def Definition) (*Site, error) {
+	if def.Name == "" {
+		def.Name = name
+	}
+	site, err := validateSite(def)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.update(name, site, func() error {
+		return s.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(sitesBucket)
+			if err := b.Delete([]byte(name)); err != nil {
+				return err
+			}
+			return putSite(b, site)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return site, nil
+}
+
+func (s *boltSites) Delete(name string) error {
+	return s.delete(name, func() error {
+		return s.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(sitesBucket).Delete([]byte(name))
+		})
+	})
+}
+
+// Save persists site status. Concurrent saves are batched into a single transaction.
+// Site removed or replaced while being checked isn't saved
+func (s *boltSites) Save(site *Site) error {
+	return s.whileListed(site, func() error {
+		return s.db.Batch(func(tx *bolt.Tx) error {
+			return putSite(tx.Bucket(sitesBucket), site)
+		})
+	})
+}
+
+func (s *boltSites) Close() {
+	if s.db == nil {
+		return
+	}
+	if err := s.db.Close(); err != nil {
+		log.Printf("[ERROR] failed to close %s sites db: %+v", s.dbPath, err)
+	}
+}
+
+func (s *boltSites) load() ([]*Site, error) {
+	var sites []*Site
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sitesBucket).ForEach(func(k, v []byte) error {
+			var rec siteRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				log.Printf("[ERROR] failed to decode %s site record: %+v", k, err)
+				return nil
+			}
+
+			site, err := newSite(rec.Definition)
+			if err != nil {
+				log.Printf("[ERROR] failed to parse %s site: %+v", k, err)
+				return nil
+			}
+			site.restore(Status{
+				Alive:     rec.Alive,
+				Latency:   rec.Latency,
+				CheckedAt: rec.CheckedAt,
+				ChangedAt: rec.ChangedAt,
+				Error:     rec.Error,
+
+				ConsecutiveFailures:  rec.ConsecutiveFailures,
+				ConsecutiveSuccesses: rec.ConsecutiveSuccesses,
+			})
+			sites = append(sites, site)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load sites from %s db: %v", s.dbPath, err)
+	}
+
+	return sites, nil
+}
+
+// seed imports sites from sites file to db
+func (s *boltSites) seed() ([]*Site, error) {
+	file := NewFileSitesService(s.seedPath)
+	if err := file.Warmup(); err != nil {
+		return nil, err
+	}
+	sites := file.GetAll()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sitesBucket)
+		for _, site := range sites {
+			if err := putSite(b, site); err != nil {
+				return err
+			}
+		}
+		return markSeeded(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sites, nil
+}
+
+// seeded reports whether sites file was imported to db already
+func (s *boltSites) seeded() bool {
+	var seeded bool
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		seeded = tx.Bucket(metaBucket).Get(seededKey) != nil
+		return nil
+	})
+	return seeded
+}
+
+func markSeeded(tx *bolt.Tx) error {
+	return tx.Bucket(metaBucket).Put(seededKey, []byte(time.Now().UTC().Format(time.RFC3339)))
+}
+
+func (s *boltSites) put(site *Site) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putSite(tx.Bucket(sitesBucket), site)
+	})
+}
+
+func putSite(b *bolt.Bucket, site *Site) error {
+	status := site.Status()
+	data, err := json.Marshal(siteRecord{
+		Definition: site.Definition(),
+		Alive:      status.Alive,
+		Latency:    status.Latency,
+		CheckedAt:  status.CheckedAt,
+		ChangedAt:  status.ChangedAt,
+		Error:      status.Error,
+
+		ConsecutiveFailures:  status.ConsecutiveFailures,
+		ConsecutiveSuccesses: status.ConsecutiveSuccesses,
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(site.Name), data)
+}
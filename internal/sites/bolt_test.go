@@ -0,0 +1,190 @@
+package sites
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltSites_Seed(t *testing.T) {
+	path, teardown := prepFile(t)
+	defer teardown()
+	dbPath, dbTeardown := prepDb(t)
+	defer dbTeardown()
+
+	s := NewBoltSitesService(dbPath, path)
+	assert.NoError(t, s.Warmup())
+	assert.Equal(t, 4, len(s.GetAll()))
+	s.Close()
+
+	// seed file is ignored once db is filled
+	s = NewBoltSitesService(dbPath, "/tmp/unknown_sites.txt")
+	assert.NoError(t, s.Warmup())
+	assert.Equal(t, 4, len(s.GetAll()))
+	s.Close()
+}
+
+func TestBoltSites_SeedOnce(t *testing.T) {
+	path, teardown := prepFile(t)
+	defer teardown()
+	dbPath, dbTeardown := prepDb(t)
+	defer dbTeardown()
+
+	s := NewBoltSitesService(dbPath, path)
+	assert.NoError(t, s.Warmup())
+	for _, site := range s.GetAll() {
+		assert.NoError(t, s.Delete(site.Name))
+	}
+	s.Close()
+
+	// deleted sites aren't imported again
+	s = NewBoltSitesService(dbPath, path)
+	assert.NoError(t, s.Warmup())
+	assert.Empty(t, s.GetAll())
+	s.Close()
+}
+
+func TestBoltSites_Save(t *testing.T) {
+	path, teardown := prepFile(t)
+	defer teardown()
+	dbPath, dbTeardown := prepDb(t)
+	defer dbTeardown()
+
+	s := NewBoltSitesService(dbPath, path)
+	assert.NoError(t, s.Warmup())
+
+	google, err := s.Get("google.com")
+	assert.NoError(t, err)
+	google.MarkAvailable(time.Second)
+	assert.NoError(t, s.Save(google))
+	s.Close()
+
+	s = NewBoltSitesService(dbPath, "")
+	assert.NoError(t, s.Warmup())
+	defer s.Close()
+
+	google, err = s.Get("google.com")
+	assert.NoError(t, err)
+	assert.True(t, google.Status().Alive)
+	assert.Equal(t, time.Second, google.Status().Latency)
+	assert.Equal(t, 1, len(s.GetAvailable()))
+}
+
+func TestBoltSites_AddUpdateDelete(t *testing.T) {
+	dbPath, dbTeardown := prepDb(t)
+	defer dbTeardown()
+
+	s := NewBoltSitesService(dbPath, "")
+	assert.NoError(t, s.Warmup())
+	assert.Equal(t, 0, len(s.GetAll()))
+
+	_, err := s.Add(Definition{Name: "api", Url: "https://api.example.com", Method: "HEAD"})
+	assert.NoError(t, err)
+	_, err = s.Add(Definition{Url: "google.com"})
+	assert.NoError(t, err)
+	_, err = s.Add(Definition{Url: "google.com"})
+	assert.IsType(t, &AlreadyExistsError{}, err)
+
+	_, err = s.Update("api", Definition{Name: "api-v2", Url: "https://api.example.com/v2"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Delete("google.com"))
+	s.Close()
+
+	s = NewBoltSitesService(dbPath, "")
+	assert.NoError(t, s.Warmup())
+	defer s.Close()
+
+	sites := s.GetAll()
+	assert.Equal(t, 1, len(sites))
+	assert.Equal(t, "api-v2", sites[0].Name)
+	assert.Equal(t, "https://api.example.com/v2", sites[0].Url.String())
+	assert.Equal(t, "GET", sites[0].Method)
+}
+
+func prepDb(t *testing.T) (string, func()) {
+	path := "/tmp/test_sites.db"
+	os.Remove(path)
+
+	return path, func() {
+		os.Remove(path)
+	}
+}
+
+func TestBoltSites_SaveDeleted(t *testing.T) {
+	dbPath, dbTeardown := prepDb(t)
+	defer dbTeardown()
+
+	s := NewBoltSitesService(dbPath, "")
+	assert.NoError(t, s.Warmup())
+
+	// saves racing with delete never bring deleted site back
+	for i := 0; i < 20; i++ {
+		site, err := s.Add(Definition{Url: "google.com"})
+		assert.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, s.Save(site))
+			}()
+		}
+		assert.NoError(t, s.Delete("google.com"))
+		wg.Wait()
+	}
+	s.Close()
+
+	s = NewBoltSitesService(dbPath, "")
+	assert.NoError(t, s.Warmup())
+	defer s.Close()
+	assert.Equal(t, 0, len(s.GetAll()))
+}
+
+func TestSiteList_WhileListedDoesntBlockReaders(t *testing.T) {
+	var l siteList
+	site := &Site{Name: "google.com"}
+	assert.NoError(t, l.add(site, nil))
+
+	saving, saved := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = l.whileListed(site, func() error {
+			close(saving)
+			<-saved
+			return nil
+		})
+	}()
+	<-saving
+
+	deleted := make(chan struct{})
+	go func() {
+		assert.NoError(t, l.delete("google.com", nil))
+		close(deleted)
+	}()
+	// let delete wait for save
+	time.Sleep(50 * time.Millisecond)
+
+	// readers aren't blocked by save and delete waiting for it
+	read := make(chan struct{})
+	go func() {
+		l.GetAll()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("GetAll is blocked by save")
+	}
+	select {
+	case <-deleted:
+		t.Fatal("site is deleted while being saved")
+	default:
+	}
+
+	close(saved)
+	<-deleted
+	assert.Empty(t, l.GetAll())
+}
@@ -0,0 +1,76 @@
+package sites
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Definition describes a single site entry of structured (YAML or JSON) sites file.
+// Timeout and Interval are Go duration strings, e.g. "5s" or "1m30s".
+type Definition struct {
+	Name    string            `yaml:"name" json:"name"`
+	Url     string            `yaml:"url" json:"url"`
+	Method  string            `yaml:"method" json:"method,omitempty"`
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty"`
+	Body    string            `yaml:"body" json:"body,omitempty"`
+	// ExpectedStatus is a shorthand for Expect.Status listing single codes
+	ExpectedStatus []int        `yaml:"expected_status" json:"expected_status,omitempty"`
+	Expect         *Expectation `yaml:"expect" json:"expect,omitempty"`
+	Timeout        string       `yaml:"timeout" json:"timeout,omitempty"`
+	Interval       string       `yaml:"interval" json:"interval,omitempty"`
+	// Tags group sites, e.g. by team or environment
+	Tags []string `yaml:"tags" json:"tags,omitempty"`
+}
+
+type fileFormat int
+
+const (
+	formatPlain fileFormat = iota
+	formatYAML
+	formatJSON
+)
+
+// detectFormat guesses sites file format by it's extension
+func detectFormat(path string) fileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".json":
+		return formatJSON
+	default:
+		return formatPlain
+	}
+}
+
+// parseDefinitions decodes list of site definitions from YAML or JSON document
+func parseDefinitions(r io.Reader, format fileFormat) ([]Definition, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []Definition
+	switch format {
+	case formatYAML:
+		err = yaml.UnmarshalStrict(data, &defs)
+	case formatJSON:
+		// unknown fields are rejected as in YAML so that typos don't go unnoticed
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&defs)
+	default:
+		return nil, fmt.Errorf("Unsupported sites file format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
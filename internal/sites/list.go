@@ -0,0 +1,170 @@
+package sites
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// siteList is concurrency safe sites list shared by Service implementations.
+// The list is copied on write so callers may iterate over GetAll result without locking
+type siteList struct {
+	lock  sync.RWMutex
+	sites []*Site
+
+	// persistLock is held by list changes exclusively and by whileListed calls shared,
+	// so that readers aren't blocked while site statuses are persisted
+	persistLock sync.RWMutex
+}
+
+func (l *siteList) Get(name string) (*Site, error) {
+	sites := l.GetAll()
+	if i := indexOf(sites, name); i >= 0 {
+		return sites[i], nil
+	}
+
+	return nil, &NotFoundError{name}
+}
+
+func (l *siteList) GetAll() []*Site {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.sites
+}
+
+func (l *siteList) GetAvailable() []*Site {
+	sites := l.GetAll()
+	availableSites := make([]*Site, 0, len(sites))
+
+	for _, site := range sites {
+		if site.Status().Alive {
+			availableSites = append(availableSites, site)
+		}
+	}
+
+	return availableSites
+}
+
+func (l *siteList) GetSortedByLatency() []*Site {
+	sites := l.GetAvailable()
+
+	// latencies are snapshotted once so concurrent checks don't break ordering
+	latencies := make(map[*Site]time.Duration, len(sites))
+	for _, site := range sites {
+		latencies[site] = site.Status().Latency
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		return latencies[sites[i]] < latencies[sites[j]]
+	})
+
+	return sites
+}
+
+// add appends site to list. Optional persist func is called under lock before the list is changed
+func (l *siteList) add(site *Site, persist func() error) error {
+	l.persistLock.Lock()
+	defer l.persistLock.Unlock()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if indexOf(l.sites, site.Name) >= 0 {
+		return &AlreadyExistsError{site.Name}
+	}
+	if persist != nil {
+		if err := persist(); err != nil {
+			return err
+		}
+	}
+
+	sites := make([]*Site, len(l.sites), len(l.sites)+1)
+	copy(sites, l.sites)
+	l.sites = append(sites, site)
+
+	return nil
+}
+
+// update replaces site by name. Optional persist func is called under lock before the list is changed
+func (l *siteList) update(name string, site *Site, persist func() error) error {
+	l.persistLock.Lock()
+	defer l.persistLock.Unlock()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	i := indexOf(l.sites, name)
+	if i < 0 {
+		return &NotFoundError{name}
+	}
+	if site.Name != name && indexOf(l.sites, site.Name) >= 0 {
+		return &AlreadyExistsError{site.Name}
+	}
+	if persist != nil {
+		if err := persist(); err != nil {
+			return err
+		}
+	}
+
+	sites := make([]*Site, len(l.sites))
+	copy(sites, l.sites)
+	sites[i] = site
+	l.sites = sites
+
+	return nil
+}
+
+// delete removes site by name. Optional persist func is called under lock before the list is changed
+func (l *siteList) delete(name string, persist func() error) error {
+	l.persistLock.Lock()
+	defer l.persistLock.Unlock()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	i := indexOf(l.sites, name)
+	if i < 0 {
+		return &NotFoundError{name}
+	}
+	if persist != nil {
+		if err := persist(); err != nil {
+			return err
+		}
+	}
+
+	sites := make([]*Site, 0, len(l.sites)-1)
+	sites = append(sites, l.sites[:i]...)
+	l.sites = append(sites, l.sites[i+1:]...)
+
+	return nil
+}
+
+// whileListed calls fn if site is in the list. The list isn't changed until fn returns
+// so that fn doesn't race with persist funcs of add, update and delete
+func (l *siteList) whileListed(site *Site, fn func() error) error {
+	l.persistLock.RLock()
+	defer l.persistLock.RUnlock()
+
+	sites := l.GetAll()
+	if i := indexOf(sites, site.Name); i < 0 || sites[i] != site {
+		return nil
+	}
+	return fn()
+}
+
+// merge replaces list with fresh sites keeping unchanged ones, see mergeSites
+func (l *siteList) merge(fresh []*Site) (added, removed int) {
+	l.persistLock.Lock()
+	defer l.persistLock.Unlock()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.sites, added, removed = mergeSites(l.sites, fresh)
+	return added, removed
+}
+
+func indexOf(sites []*Site, name string) int {
+	for i, site := range sites {
+		if site.Name == name {
+			return i
+		}
+	}
+	return -1
+}
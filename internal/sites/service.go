@@ -3,111 +3,229 @@ package sites
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
-	"net/url"
 	"os"
+	"reflect"
 	"sort"
+	"sync"
 )
 
 type Service interface {
 	Warmup() error
+	// Reload re-reads sites source keeping status of unchanged sites
+	Reload() error
+	// Watch reloads sites on source changes until ctx is done
+	Watch(ctx context.Context) error
 
+	Get(name string) (*Site, error)
 	GetAll() []*Site
 	GetAvailable() []*Site
 	GetSortedByLatency() []*Site
 
+	Add(def Definition) (*Site, error)
+	Update(name string, def Definition) (*Site, error)
+	Delete(name string) error
+	// Save persists current site status
+	Save(site *Site) error
+
 	Close()
 }
 
 func NewFileSitesService(path string) Service {
-	return &fileSites{filePath: path}
+	return &fileSites{
+		filePath:  path,
+		overrides: make(map[string]Definition),
+		deleted:   make(map[string]bool),
+	}
 }
 
 type fileSites struct {
-	sites []*Site
+	siteList
 
 	filePath string
+
+	// runtimeLock serializes runtime changes with reloads
+	runtimeLock sync.Mutex
+	// overrides are sites added or updated at runtime, they take precedence over sites file
+	overrides map[string]Definition
+	// deleted are sites removed at runtime, they are skipped when found in sites file
+	deleted map[string]bool
 }
 
 func (s *fileSites) Warmup() error {
+	return s.Reload()
+}
+
+func (s *fileSites) Reload() error {
 	file, err := s.readFile(s.filePath)
 	if err != nil {
 		return err
 	}
-	s.parseSites(file)
+
+	sites, err := s.parseSites(file)
+	if err != nil {
+		return err
+	}
+
+	s.runtimeLock.Lock()
+	defer s.runtimeLock.Unlock()
+
+	sites = s.applyRuntime(sites)
+	added, removed := s.merge(sites)
+	log.Printf("[INFO] loaded %d sites from %s: %d added, %d removed", len(sites), s.filePath, added, removed)
 
 	return nil
 }
 
-func (s *fileSites) GetAll() []*Site {
-	return s.sites
+func (s *fileSites) Watch(ctx context.Context) error {
+	return watchFile(ctx, s.filePath, s.Reload)
 }
 
-func (s *fileSites) GetAvailable() []*Site {
-	availableSites := make([]*Site, 0, len(s.sites))
+// Add registers new site. Sites added at runtime live in memory and are kept on sites file reloads
+func (s *fileSites) Add(def Definition) (*Site, error) {
+	site, err := validateSite(def)
+	if err != nil {
+		return nil, err
+	}
+
+	s.runtimeLock.Lock()
+	defer s.runtimeLock.Unlock()
 
-	for _, site := range s.GetAll() {
-		if site.Alive {
-			availableSites = append(availableSites, site)
+	err = s.add(site, func() error {
+		s.overrides[site.Name] = site.Definition()
+		delete(s.deleted, site.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return site, nil
+}
+
+// Update replaces definition of site by name. Site status is reset
+func (s *fileSites) Update(name string, def Definition) (*Site, error) {
+	if def.Name == "" {
+		def.Name = name
+	}
+	site, err := validateSite(def)
+	if err != nil {
+		return nil, err
+	}
+
+	s.runtimeLock.Lock()
+	defer s.runtimeLock.Unlock()
+
+	err = s.update(name, site, func() error {
+		if site.Name != name {
+			delete(s.overrides, name)
+			s.deleted[name] = true
 		}
+		s.overrides[site.Name] = site.Definition()
+		delete(s.deleted, site.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return availableSites
+	return site, nil
 }
 
-func (s *fileSites) GetSortedByLatency() []*Site {
-	sites := s.GetAvailable()
+// Delete removes site, site is skipped on sites file reloads until it's added again
+func (s *fileSites) Delete(name string) error {
+	s.runtimeLock.Lock()
+	defer s.runtimeLock.Unlock()
 
-	sort.Slice(sites, func(i, j int) bool {
-		return sites[i].Latency < sites[j].Latency
+	return s.delete(name, func() error {
+		delete(s.overrides, name)
+		s.deleted[name] = true
+		return nil
 	})
+}
 
-	return sites
+// status isn't persisted in sites file
+func (s *fileSites) Save(site *Site) error {
+	return nil
 }
 
 // nothing to finalize
 func (s *fileSites) Close() {}
 
-func (s *fileSites) addSite(rawurl string) error {
-	url, err := url.Parse(rawurl)
-	if err != nil {
-		return fmt.Errorf("Falied to parse %s site: %v", rawurl, err)
+// applyRuntime applies runtime changes over sites parsed from file. Must be called under runtimeLock
+func (s *fileSites) applyRuntime(sites []*Site) []*Site {
+	res := make([]*Site, 0, len(sites)+len(s.overrides))
+	for _, site := range sites {
+		if _, ok := s.overrides[site.Name]; ok || s.deleted[site.Name] {
+			continue
+		}
+		res = append(res, site)
 	}
 
-	if url.String() == "" {
-		return nil
+	names := make([]string, 0, len(s.overrides))
+	for name := range s.overrides {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	for _, name := range names {
+		// definitions were validated on add
+		site, err := newSite(s.overrides[name])
+		if err != nil {
+			log.Printf("[ERROR] failed to restore %s site: %+v", name, err)
+			continue
+		}
+		res = append(res, site)
+	}
+
+	return res
+}
 
-	if url.Scheme == "" {
-		url.Scheme = "http"
+func (s *fileSites) parseSites(r io.Reader) ([]*Site, error) {
+	format := detectFormat(s.filePath)
+	if format == formatPlain {
+		return s.parsePlainSites(r)
 	}
 
-	site := &Site{
-		Name: rawurl,
-		Url:  url,
+	defs, err := parseDefinitions(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("Falied to parse %s sites file: %v", s.filePath, err)
 	}
-	s.sites = append(s.sites, site)
 
-	return nil
+	sites := make([]*Site, 0, len(defs))
+	for _, def := range defs {
+		sites, err = addSite(sites, def)
+		if err != nil {
+			log.Printf("[ERROR] failed to parse %s site: %+v", def.Url, err)
+		}
+	}
+
+	return sites, nil
 }
 
-func (s *fileSites) parseSites(sites io.Reader) error {
-	scanner := bufio.NewScanner(sites)
+// parsePlainSites reads one bare site url per line
+func (s *fileSites) parsePlainSites(r io.Reader) ([]*Site, error) {
+	var (
+		sites []*Site
+		err   error
+	)
+
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		name := scanner.Text()
-		err := s.addSite(name)
+		sites, err = addSite(sites, Definition{Url: name})
 		if err != nil {
 			log.Printf("[ERROR] failed to parse %s site: %+v", name, err)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("Falied to read %s sites file: %v", s.filePath, err)
+		return nil, fmt.Errorf("Falied to read %s sites file: %v", s.filePath, err)
 	}
 
-	return nil
+	return sites, nil
 }
 
 func (s *fileSites) readFile(path string) (io.Reader, error) {
@@ -123,3 +241,55 @@ func (s *fileSites) readFile(path string) (io.Reader, error) {
 
 	return buf, nil
 }
+
+func addSite(sites []*Site, def Definition) ([]*Site, error) {
+	if def.Url == "" {
+		return sites, nil
+	}
+
+	site, err := newSite(def)
+	if err != nil {
+		return sites, err
+	}
+
+	return append(sites, site), nil
+}
+
+// validateSite builds site from definition received from user
+func validateSite(def Definition) (*Site, error) {
+	if def.Url == "" {
+		return nil, &ValidationError{fmt.Errorf("Site url is required")}
+	}
+
+	site, err := newSite(def)
+	if err != nil {
+		return nil, &ValidationError{err}
+	}
+
+	return site, nil
+}
+
+// mergeSites returns fresh sites list where sites with unchanged definitions
+// are taken from old list so their status is preserved
+func mergeSites(old, fresh []*Site) (merged []*Site, added, removed int) {
+	known := make(map[string]*Site, len(old))
+	for _, site := range old {
+		known[site.Name] = site
+	}
+
+	merged = make([]*Site, 0, len(fresh))
+	for _, site := range fresh {
+		prev, ok := known[site.Name]
+		if ok && reflect.DeepEqual(prev.Definition(), site.Definition()) {
+			merged = append(merged, prev)
+		} else {
+			merged = append(merged, site)
+			added++
+		}
+		delete(known, site.Name)
+	}
+	// changed sites are counted as removed and added again
+	removed = len(old) - (len(merged) - added)
+
+	return merged, added, removed
+}
@@ -1,9 +1,12 @@
 package sites
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -53,19 +56,371 @@ func TestFileSites_GetSortedByLatency(t *testing.T) {
 
 	sites = s.GetSortedByLatency()
 	sorted := sort.SliceIsSorted(sites, func(i, j int) bool {
-		return sites[i].Latency < sites[j].Latency
+		return sites[i].Status().Latency < sites[j].Status().Latency
 	})
 
 	assert.True(t, sorted)
 }
 
+func TestFileSites_WarmUp_YAML(t *testing.T) {
+	content := `
+- name: google
+  url: https://google.com
+  method: HEAD
+  headers:
+    User-Agent: status-board
+  expected_status: [200, 301]
+  timeout: 3s
+  interval: 30s
+  tags: [search, prod]
+- url: youtube.com
+`
+	path, teardown := prepFileContent(t, "/tmp/test_sites.yaml", content)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+
+	err := s.Warmup()
+	assert.NoError(t, err)
+
+	sites := s.GetAll()
+	assert.Equal(t, 2, len(sites))
+
+	google := sites[0]
+	assert.Equal(t, "google", google.Name)
+	assert.Equal(t, "https://google.com", google.Url.String())
+	assert.Equal(t, "HEAD", google.Method)
+	assert.Equal(t, "status-board", google.Headers["User-Agent"])
+	assert.NoError(t, google.Assertions.CheckStatus(301))
+	assert.Error(t, google.Assertions.CheckStatus(302))
+	assert.Equal(t, 3*time.Second, google.Timeout)
+	assert.Equal(t, 30*time.Second, google.Interval)
+	assert.True(t, google.HasTag("prod"))
+	assert.False(t, google.HasTag("video"))
+
+	youtube := sites[1]
+	assert.Equal(t, "youtube.com", youtube.Name)
+	assert.Equal(t, "http://youtube.com", youtube.Url.String())
+	assert.Equal(t, "GET", youtube.Method)
+	assert.Nil(t, youtube.Assertions)
+	assert.NoError(t, youtube.Assertions.CheckStatus(404))
+	assert.EqualError(t, youtube.Assertions.CheckStatus(503), "unexpected status 503")
+}
+
+func TestFileSites_WarmUp_JSON(t *testing.T) {
+	content := `[
+		{"name": "api", "url": "https://api.example.com/health", "method": "POST", "body": "{}", "expected_status": [204]},
+		{"name": "broken", "url": "https://example.com", "timeout": "forever"}
+	]`
+	path, teardown := prepFileContent(t, "/tmp/test_sites.json", content)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+
+	err := s.Warmup()
+	assert.NoError(t, err)
+
+	// site with invalid timeout is skipped
+	sites := s.GetAll()
+	assert.Equal(t, 1, len(sites))
+	assert.Equal(t, "POST", sites[0].Method)
+	assert.Equal(t, "{}", sites[0].Body)
+	assert.NoError(t, sites[0].Assertions.CheckStatus(204))
+	assert.Error(t, sites[0].Assertions.CheckStatus(200))
+}
+
+func TestFileSites_WarmUp_InvalidYAML(t *testing.T) {
+	path, teardown := prepFileContent(t, "/tmp/test_sites.yml", "- url: google.com\n  unknown: field\n")
+	defer teardown()
+
+	s := NewFileSitesService(path)
+
+	err := s.Warmup()
+	assert.Error(t, err)
+}
+
+func TestFileSites_WarmUp_InvalidJSON(t *testing.T) {
+	path, teardown := prepFileContent(t, "/tmp/test_sites.json", `[{"url": "google.com", "interavl": "1m"}]`)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+
+	err := s.Warmup()
+	assert.Error(t, err)
+}
+
+func TestFileSites_Reload(t *testing.T) {
+	path, teardown := prepFileContent(t, "/tmp/test_sites_reload.txt", "google.com\nyoutube.com\n")
+	defer teardown()
+
+	s := NewFileSitesService(path)
+	assert.NoError(t, s.Warmup())
+
+	google := s.GetAll()[0]
+	google.MarkAvailable(time.Second)
+
+	err := ioutil.WriteFile(path, []byte("google.com\nfacebook.com\n"), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Reload())
+
+	sites := s.GetAll()
+	assert.Equal(t, 2, len(sites))
+	// unchanged site keeps it's status
+	assert.True(t, sites[0] == google)
+	assert.True(t, sites[0].Status().Alive)
+	assert.Equal(t, time.Second, sites[0].Status().Latency)
+	assert.Equal(t, "facebook.com", sites[1].Name)
+	assert.False(t, sites[1].Status().Alive)
+}
+
+func TestFileSites_Watch(t *testing.T) {
+	path, teardown := prepFileContent(t, "/tmp/test_sites_watch.txt", "google.com\n")
+	defer teardown()
+
+	s := NewFileSitesService(path)
+	assert.NoError(t, s.Warmup())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, s.Watch(ctx))
+
+	err := ioutil.WriteFile(path, []byte("google.com\nyoutube.com\n"), 0644)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return len(s.GetAll()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestFileSites_AddUpdateDelete(t *testing.T) {
+	path, teardown := prepFile(t)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+	assert.NoError(t, s.Warmup())
+	before := s.GetAll()
+
+	site, err := s.Add(Definition{Url: "example.com", Method: "HEAD"})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", site.Name)
+	assert.Equal(t, "http://example.com", site.Url.String())
+	assert.Equal(t, 5, len(s.GetAll()))
+	// previously returned list is untouched
+	assert.Equal(t, 4, len(before))
+
+	_, err = s.Add(Definition{Url: "example.com"})
+	assert.IsType(t, &AlreadyExistsError{}, err)
+
+	_, err = s.Add(Definition{Name: "no-url"})
+	assert.IsType(t, &ValidationError{}, err)
+
+	_, err = s.Add(Definition{Url: "example.org", Timeout: "soon"})
+	assert.IsType(t, &ValidationError{}, err)
+
+	site, err = s.Update("example.com", Definition{Url: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", site.Name)
+	assert.Equal(t, "GET", site.Method)
+
+	found, err := s.Get("example.com")
+	assert.NoError(t, err)
+	assert.True(t, found == site)
+
+	_, err = s.Update("example.com", Definition{Name: "google.com", Url: "google.com"})
+	assert.IsType(t, &AlreadyExistsError{}, err)
+
+	_, err = s.Update("unknown", Definition{Url: "google.com"})
+	assert.IsType(t, &NotFoundError{}, err)
+
+	assert.NoError(t, s.Delete("example.com"))
+	assert.Equal(t, 4, len(s.GetAll()))
+
+	_, err = s.Get("example.com")
+	assert.IsType(t, &NotFoundError{}, err)
+	assert.IsType(t, &NotFoundError{}, s.Delete("example.com"))
+}
+
 func prepFile(t *testing.T) (string, func()) {
-	path := "/tmp/test_sites.txt"
-	sites := []byte("google.com\nhttp://youtube.com\nhttps://www.facebook.com\n\ninvalid.site\n")
-	err := ioutil.WriteFile(path, sites, 0644)
+	return prepFileContent(t, "/tmp/test_sites.txt", "google.com\nhttp://youtube.com\nhttps://www.facebook.com\n\ninvalid.site\n")
+}
+
+func prepFileContent(t *testing.T, path, content string) (string, func()) {
+	err := ioutil.WriteFile(path, []byte(content), 0644)
 	assert.NoError(t, err)
 
 	return path, func() {
 		os.Remove(path)
 	}
 }
+
+func TestFileSites_RuntimeChangesSurviveReload(t *testing.T) {
+	path, teardown := prepFile(t)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+	assert.NoError(t, s.Warmup())
+
+	added, err := s.Add(Definition{Url: "example.com"})
+	assert.NoError(t, err)
+	_, err = s.Update("google.com", Definition{Url: "https://google.com"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Delete("invalid.site"))
+
+	assert.NoError(t, s.Reload())
+	assert.Equal(t, 4, len(s.GetAll()))
+
+	// unchanged runtime site keeps it's status
+	site, err := s.Get("example.com")
+	assert.NoError(t, err)
+	assert.True(t, site == added)
+
+	site, err = s.Get("google.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://google.com", site.Url.String())
+
+	_, err = s.Get("invalid.site")
+	assert.IsType(t, &NotFoundError{}, err)
+
+	// deleted site is back once added again
+	_, err = s.Add(Definition{Url: "invalid.site"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Delete("example.com"))
+	assert.NoError(t, s.Reload())
+
+	_, err = s.Get("invalid.site")
+	assert.NoError(t, err)
+	_, err = s.Get("example.com")
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestSite_Record(t *testing.T) {
+	site, err := newSite(Definition{Url: "google.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, StateUnknown, site.State())
+
+	now := time.Now()
+	prev, cur := site.Record(Result{CheckedAt: now, Alive: true, Latency: time.Second}, Hysteresis{})
+	assert.Equal(t, StateUnknown, prev.State())
+	assert.Equal(t, Status{Alive: true, Latency: time.Second, CheckedAt: now, ChangedAt: now, ConsecutiveSuccesses: 1}, cur)
+
+	// the last known latency is kept on failure
+	prev, cur = site.Record(Result{CheckedAt: now.Add(time.Minute), Error: "timeout"}, Hysteresis{})
+	assert.Equal(t, StateUp, prev.State())
+	assert.Equal(t, Status{Latency: time.Second, CheckedAt: now.Add(time.Minute), ChangedAt: now.Add(time.Minute), Error: "timeout", ConsecutiveFailures: 1}, cur)
+	assert.Equal(t, cur, site.Status())
+	assert.Equal(t, StateDown, site.State())
+}
+
+func TestSite_RecordHysteresis(t *testing.T) {
+	site, err := newSite(Definition{Url: "google.com"})
+	assert.NoError(t, err)
+
+	h := Hysteresis{Down: 3, Up: 2}
+	now := time.Now()
+	record := func(alive bool) Status {
+		now = now.Add(time.Minute)
+		res := Result{CheckedAt: now, Alive: alive}
+		if !alive {
+			res.Error = "timeout"
+		}
+		_, cur := site.Record(res, h)
+		return cur
+	}
+
+	// the first result sets state right away
+	assert.True(t, record(true).Alive)
+
+	// site stays up until Down failures in a row
+	cur := record(false)
+	assert.True(t, cur.Alive)
+	assert.Equal(t, "timeout", cur.Error)
+	assert.Equal(t, 1, cur.ConsecutiveFailures)
+	assert.True(t, record(false).Alive)
+	assert.True(t, record(true).Alive)
+	assert.True(t, record(false).Alive)
+	assert.True(t, record(false).Alive)
+	cur = record(false)
+	assert.False(t, cur.Alive)
+	assert.Equal(t, 3, cur.ConsecutiveFailures)
+	assert.Equal(t, now, cur.ChangedAt)
+	downAt := now
+
+	// and stays down until Up successes in a row
+	cur = record(true)
+	assert.False(t, cur.Alive)
+	assert.Equal(t, 0, cur.ConsecutiveFailures)
+	assert.Equal(t, 1, cur.ConsecutiveSuccesses)
+	assert.Equal(t, downAt, cur.ChangedAt)
+	assert.False(t, record(false).Alive)
+	assert.False(t, record(true).Alive)
+	assert.True(t, record(true).Alive)
+}
+
+func TestSite_RecordCertificate(t *testing.T) {
+	site, err := newSite(Definition{Url: "https://google.com"})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	cert := &Certificate{Issuer: "CN=CA", NotAfter: now.Add(36 * time.Hour)}
+	_, cur := site.Record(Result{CheckedAt: now, Alive: true, Certificate: cert, Degraded: true}, Hysteresis{})
+	assert.Equal(t, StateDegraded, cur.State())
+	assert.Equal(t, 1, cur.Certificate.DaysToExpiry(now))
+	assert.Equal(t, -1, cur.Certificate.DaysToExpiry(now.Add(48*time.Hour)))
+
+	// certificate is kept if check failed before response
+	_, cur = site.Record(Result{CheckedAt: now, Error: "timeout"}, Hysteresis{})
+	assert.Equal(t, StateDown, cur.State())
+	assert.Equal(t, cert, cur.Certificate)
+
+	_, cur = site.Record(Result{CheckedAt: now, Alive: true, Certificate: cert}, Hysteresis{})
+	assert.Equal(t, StateUp, cur.State())
+}
+
+func TestFileSites_ConcurrentRecord(t *testing.T) {
+	path, teardown := prepFile(t)
+	defer teardown()
+
+	s := NewFileSitesService(path)
+	assert.NoError(t, s.Warmup())
+
+	// every field of i-th result is derived from i so that fields of different results don't match
+	base := time.Now()
+	result := func(i int) Result {
+		res := Result{CheckedAt: base.Add(time.Duration(i)), QueueWait: time.Duration(i), Alive: i%2 == 0}
+		if res.Alive {
+			res.Latency = time.Duration(i)
+		} else {
+			res.Error = fmt.Sprintf("check %d failed", i)
+		}
+		return res
+	}
+
+	var wg sync.WaitGroup
+	for _, site := range s.GetAll() {
+		wg.Add(1)
+		go func(site *Site) {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				site.Record(result(i), Hysteresis{})
+			}
+		}(site)
+	}
+
+	for i := 0; i < 100; i++ {
+		for _, site := range s.GetSortedByLatency() {
+			status := site.Status()
+			if status.CheckedAt.IsZero() {
+				continue
+			}
+			// snapshot is never torn
+			n := int(status.CheckedAt.Sub(base))
+			assert.Equal(t, time.Duration(n), status.QueueWait)
+			assert.Equal(t, result(n).Error, status.Error)
+			assert.Equal(t, status.Alive, status.Error == "")
+			if status.Alive {
+				assert.Equal(t, time.Duration(n), status.Latency)
+			}
+		}
+	}
+	wg.Wait()
+}
@@ -1,22 +1,323 @@
 package sites
 
 import (
+	"fmt"
+	"math"
+	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
 )
 
+type NotFoundError struct {
+	name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Unknown site: %s", e.name)
+}
+
+type AlreadyExistsError struct {
+	name string
+}
+
+func (e *AlreadyExistsError) Error() string {
+	return fmt.Sprintf("Site already exists: %s", e.name)
+}
+
+type ValidationError struct {
+	err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.err.Error()
+}
+
+// Site is a checked resource. Definition fields are immutable once site is created,
+// mutable status is guarded and read through Status snapshots
 type Site struct {
 	Name    string
 	Url     *url.URL
+	Method  string
+	Headers map[string]string
+	Body    string
+	// Assertions define check success criteria, any response is accepted if nil
+	Assertions *Assertions
+	// Timeout and Interval override global ask timeout and checks rate if set
+	Timeout  time.Duration
+	Interval time.Duration
+	Tags     []string
+
+	lock   sync.RWMutex
+	status Status
+
+	def Definition
+}
+
+// Status is an immutable snapshot of site status
+type Status struct {
 	Alive   bool
 	Latency time.Duration
+	// CheckedAt is zero until site is checked for the first time
+	CheckedAt time.Time
+	// ChangedAt is a time of the check site state last changed on
+	ChangedAt time.Time
+	// Error is the last failed check reason
+	Error string
+	// QueueWait is a time the last check waited for concurrency limits
+	QueueWait time.Duration
+	// Timings is the last check latency breakdown
+	Timings Timings
+	// Certificate is the last known TLS certificate, nil for plain http sites
+	Certificate *Certificate
+	// Degraded is set for available site with certificate expiring soon
+	Degraded bool
+	// Addresses are the last resolved dns answers
+	Addresses []string
+	// ConsecutiveFailures and ConsecutiveSuccesses count the latest checks with the same outcome
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+}
+
+// State returns availability state of status
+func (s Status) State() State {
+	switch {
+	case s.CheckedAt.IsZero():
+		return StateUnknown
+	case s.Alive && s.Degraded:
+		return StateDegraded
+	case s.Alive:
+		return StateUp
+	default:
+		return StateDown
+	}
+}
+
+// State is site availability state
+type State string
+
+const (
+	StateUnknown State = "unknown"
+	StateUp      State = "up"
+	StateDown    State = "down"
+	// StateDegraded is available site with certificate expiring soon
+	StateDegraded State = "degraded"
+)
+
+// Result is an outcome of a single site check
+type Result struct {
+	CheckedAt  time.Time     `json:"checked_at"`
+	Alive      bool          `json:"alive"`
+	Latency    time.Duration `json:"latency"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	// QueueWait is a time check waited for concurrency limits, it isn't included in Latency
+	QueueWait time.Duration `json:"queue_wait"`
+	// Attempts is a number of requests made, more than one if check was retried
+	Attempts int `json:"attempts,omitempty"`
+	// Timings is a latency breakdown of the last request
+	Timings Timings `json:"timings"`
+	// Certificate is set for https sites if response is received, it isn't kept in history
+	Certificate *Certificate `json:"-"`
+	// Degraded reports that certificate is expiring soon
+	Degraded bool `json:"degraded,omitempty"`
+	// Addresses are resolved answers of dns check
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// Certificate is a summary of site TLS certificate chain
+type Certificate struct {
+	Subject  string   `json:"subject"`
+	Issuer   string   `json:"issuer"`
+	DNSNames []string `json:"dns_names,omitempty"`
+	// NotAfter is the earliest expiry time across the chain
+	NotAfter time.Time `json:"not_after"`
+}
+
+// DaysToExpiry returns number of whole days left until certificate expires, negative once expired
+func (c *Certificate) DaysToExpiry(now time.Time) int {
+	return int(math.Floor(c.NotAfter.Sub(now).Hours() / 24))
+}
+
+// Timings is a breakdown of check request latency by phases, phases not reached
+// or skipped, e.g. dial of reused connection, are zero
+type Timings struct {
+	DNS     time.Duration `json:"dns"`
+	Connect time.Duration `json:"connect"`
+	TLS     time.Duration `json:"tls"`
+	// TTFB is a time from connection is ready until the first response byte
+	TTFB time.Duration `json:"ttfb"`
+	// Transfer is a time from the first response byte until body is read
+	Transfer time.Duration `json:"transfer"`
+}
+
+// Hysteresis is a number of consecutive check results required to change site state.
+// Zero values are treated as one, i.e. every result changes state
+type Hysteresis struct {
+	// Down is a number of consecutive failures to declare available site down
+	Down int
+	// Up is a number of consecutive successes to declare unavailable site up
+	Up int
+}
+
+// Status returns consistent snapshot of site status
+func (s *Site) Status() Status {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.status
+}
+
+// Record updates site status with check result and returns previous and new site status.
+// Site state is changed once h thresholds are reached, the first result sets state right away
+func (s *Site) Record(res Result, h Hysteresis) (prev, cur Status) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prev = s.status
+	s.status = Status{
+		Alive:     prev.Alive,
+		Latency:   prev.Latency,
+		CheckedAt: res.CheckedAt,
+		ChangedAt: prev.ChangedAt,
+		Error:     res.Error,
+		QueueWait: res.QueueWait,
+		Timings:   res.Timings,
+		Addresses: res.Addresses,
+
+		Certificate: prev.Certificate,
+		Degraded:    prev.Degraded,
+	}
+	// certificate is unknown if check failed before response
+	if res.Certificate != nil {
+		s.status.Certificate = res.Certificate
+		s.status.Degraded = res.Degraded
+	}
+
+	if res.Alive {
+		// the last known latency of available site is kept
+		s.status.Latency = res.Latency
+		s.status.ConsecutiveSuccesses = prev.ConsecutiveSuccesses + 1
+		if prev.CheckedAt.IsZero() || s.status.ConsecutiveSuccesses >= h.Up {
+			s.status.Alive = true
+		}
+	} else {
+		s.status.ConsecutiveFailures = prev.ConsecutiveFailures + 1
+		if prev.CheckedAt.IsZero() || s.status.ConsecutiveFailures >= h.Down {
+			s.status.Alive = false
+		}
+	}
+	if s.status.State() != prev.State() {
+		s.status.ChangedAt = res.CheckedAt
+	}
+
+	return prev, s.status
+}
+
+// State returns current site state
+func (s *Site) State() State {
+	return s.Status().State()
 }
 
 func (s *Site) MarkAvailable(latency time.Duration) {
-	s.Alive = true
-	s.Latency = latency
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.status.Alive = true
+	s.status.Latency = latency
 }
 
 func (s *Site) MarkUnavailable() {
-	s.Alive = false
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.status.Alive = false
+}
+
+// restore sets previously saved status
+func (s *Site) restore(status Status) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.status = status
+}
+
+// HasTag reports whether site is tagged with tag
+func (s *Site) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Definition returns definition site was created from
+func (s *Site) Definition() Definition {
+	return s.def
+}
+
+func newSite(def Definition) (*Site, error) {
+	url, err := normalizeURL(def.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	site := &Site{
+		Name:    def.Name,
+		Url:     url,
+		Method:  def.Method,
+		Headers: def.Headers,
+		Body:    def.Body,
+		Tags:    def.Tags,
+		def:     def,
+	}
+	if site.Name == "" {
+		site.Name = def.Url
+		site.def.Name = def.Url
+	}
+	if site.Method == "" {
+		site.Method = http.MethodGet
+	}
+
+	if def.Expect != nil || len(def.ExpectedStatus) > 0 {
+		var exp Expectation
+		if def.Expect != nil {
+			exp = *def.Expect
+			exp.Status = append([]string(nil), exp.Status...)
+		}
+		for _, code := range def.ExpectedStatus {
+			exp.Status = append(exp.Status, strconv.Itoa(code))
+		}
+		if site.Assertions, err = NewAssertions(exp); err != nil {
+			return nil, fmt.Errorf("Invalid %s site expectations: %v", site.Name, err)
+		}
+	}
+
+	if def.Timeout != "" {
+		if site.Timeout, err = time.ParseDuration(def.Timeout); err != nil {
+			return nil, fmt.Errorf("Invalid %s site timeout: %v", site.Name, err)
+		}
+	}
+	if def.Interval != "" {
+		if site.Interval, err = time.ParseDuration(def.Interval); err != nil {
+			return nil, fmt.Errorf("Invalid %s site interval: %v", site.Name, err)
+		}
+	}
+
+	return site, nil
+}
+
+func normalizeURL(rawurl string) (*url.URL, error) {
+	url, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("Falied to parse %s site: %v", rawurl, err)
+	}
+
+	if url.Scheme == "" {
+		url.Scheme = "http"
+	}
+
+	return url, nil
 }
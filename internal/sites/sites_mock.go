@@ -1,6 +1,10 @@
 package sites
 
-import "github.com/stretchr/testify/mock"
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type MockedService struct {
 	mock.Mock
@@ -11,6 +15,22 @@ func (m *MockedService) Warmup() error {
 	return args.Error(1)
 }
 
+func (m *MockedService) Reload() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockedService) Watch(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockedService) Get(name string) (*Site, error) {
+	args := m.Called(name)
+	site, _ := args.Get(0).(*Site)
+	return site, args.Error(1)
+}
+
 func (m *MockedService) GetAll() []*Site {
 	args := m.Called()
 	return args.Get(0).([]*Site)
@@ -24,4 +44,26 @@ func (m *MockedService) GetSortedByLatency() []*Site {
 	return args.Get(0).([]*Site)
 }
 
+func (m *MockedService) Add(def Definition) (*Site, error) {
+	args := m.Called(def)
+	site, _ := args.Get(0).(*Site)
+	return site, args.Error(1)
+}
+
+func (m *MockedService) Update(name string, def Definition) (*Site, error) {
+	args := m.Called(name, def)
+	site, _ := args.Get(0).(*Site)
+	return site, args.Error(1)
+}
+
+func (m *MockedService) Delete(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockedService) Save(site *Site) error {
+	args := m.Called(site)
+	return args.Error(0)
+}
+
 func (m *MockedService) Close() {}
@@ -0,0 +1,65 @@
+package sites
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay debounces bursts of file events produced by editors and config management tools
+const reloadDelay = 100 * time.Millisecond
+
+// watchFile calls reload on every change of file at path or on SIGHUP until ctx is done.
+// Parent directory is watched instead of the file itself to survive atomic renames.
+func watchFile(ctx context.Context, path string, reload func() error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("Failed to init sites file watcher: %v", err)
+	}
+
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("Failed to watch %s sites file: %v", path, err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(hup)
+
+		var delay <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				log.Printf("[INFO] SIGHUP received, reloading %s", path)
+				delay = time.After(0)
+			case ev := <-watcher.Events:
+				if filepath.Clean(ev.Name) != path || ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				delay = time.After(reloadDelay)
+			case err := <-watcher.Errors:
+				log.Printf("[ERROR] sites file watcher: %+v", err)
+			case <-delay:
+				delay = nil
+				if err := reload(); err != nil {
+					log.Printf("[ERROR] failed to reload sites: %+v", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
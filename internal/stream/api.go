@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHeartbeat is a period of comments and pings keeping idle connections open
+const defaultHeartbeat = 15 * time.Second
+
+func RegisterHandlers(r *gin.Engine, broker *Broker) {
+	res := resource{broker: broker, heartbeat: defaultHeartbeat}
+
+	r.GET("/events", res.Events)
+	r.GET("/ws", res.WS)
+}
+
+type resource struct {
+	broker    *Broker
+	heartbeat time.Duration
+}
+
+// Events streams messages as server-sent events, optionally filtered by `site` query params.
+// Buffered messages after `Last-Event-ID` header or `last_event_id` query param are sent first
+func (r *resource) Events(c *gin.Context) {
+	lastID, err := parseLastID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, replay := r.broker.Subscribe(lastID, siteFilter(c.QueryArray("site")))
+	defer r.broker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	for _, msg := range replay {
+		if err := writeEvent(c.Writer, msg); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(r.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case msg, ok := <-sub.C:
+			if !ok {
+				// client reconnects and resumes from the last received message
+				return
+			}
+			if err := writeEvent(c.Writer, msg); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+	return err
+}
+
+func parseLastID(c *gin.Context) (uint64, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid last event id %s", value)
+	}
+	return id, nil
+}
+
+// siteFilter matches messages of given sites, names may be comma separated. Nil is returned if no sites given
+func siteFilter(values []string) func(Message) bool {
+	names := make(map[string]bool)
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[name] = true
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	return func(msg Message) bool {
+		return names[msg.Site]
+	}
+}
@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+type sseEvent struct {
+	comment string
+	id      string
+	typ     string
+	data    string
+}
+
+// readEvent reads the next event or comment from stream
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return ev
+		case strings.HasPrefix(line, ":"):
+			ev.comment = strings.TrimSpace(line[1:])
+		case strings.HasPrefix(line, "id: "):
+			ev.id = line[len("id: "):]
+		case strings.HasPrefix(line, "event: "):
+			ev.typ = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			ev.data = line[len("data: "):]
+		}
+	}
+}
+
+func setupServer(b *Broker, heartbeat time.Duration) *httptest.Server {
+	r := gin.New()
+	res := resource{broker: b, heartbeat: heartbeat}
+	r.GET("/events", res.Events)
+	return httptest.NewServer(r)
+}
+
+func TestEvents(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	ts := setupServer(b, time.Hour)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events?site=google.com")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	b.OnEvent(event("vk.com", sites.StateUp, sites.StateDown))
+	b.OnEvent(event("google.com", sites.StateUp, sites.StateDown))
+
+	r := bufio.NewReader(resp.Body)
+	ev := readEvent(t, r)
+	assert.Equal(t, "3", ev.id)
+	assert.Equal(t, TypeResult, ev.typ)
+
+	var msg Message
+	assert.NoError(t, json.Unmarshal([]byte(ev.data), &msg))
+	assert.Equal(t, "google.com", msg.Site)
+	assert.Equal(t, sites.StateDown, msg.State)
+
+	ev = readEvent(t, r)
+	assert.Equal(t, "4", ev.id)
+	assert.Equal(t, TypeState, ev.typ)
+}
+
+func TestEvents_Resume(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	ts := setupServer(b, time.Hour)
+	defer ts.Close()
+
+	b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+	b.OnEvent(event("vk.com", sites.StateUp, sites.StateUp))
+	b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+
+	req, err := http.NewRequest("GET", ts.URL+"/events", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	assert.Equal(t, "2", readEvent(t, r).id)
+	assert.Equal(t, "3", readEvent(t, r).id)
+
+	b.OnEvent(event("vk.com", sites.StateUp, sites.StateUp))
+	assert.Equal(t, "4", readEvent(t, r).id)
+
+	resp.Body.Close()
+
+	// unknown id
+	resp, err = http.Get(ts.URL + "/events?last_event_id=42")
+	assert.NoError(t, err)
+	r = bufio.NewReader(resp.Body)
+	ev := readEvent(t, r)
+	assert.Equal(t, "4", ev.id)
+	assert.Equal(t, TypeReset, ev.typ)
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/events?last_event_id=first")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestEvents_Heartbeat(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	ts := setupServer(b, 10*time.Millisecond)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+	assert.Equal(t, "heartbeat", readEvent(t, r).comment)
+
+	// stream ends once broker is closed
+	b.Close()
+	for {
+		if _, err := r.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+// Message types
+const (
+	// TypeResult is published on every check result
+	TypeResult = "result"
+	// TypeState is published when site state changes
+	TypeState = "state"
+	// TypeReset is replayed instead of missed messages which aren't buffered anymore,
+	// subscriber is expected to reload the full state
+	TypeReset = "reset"
+)
+
+// Message is a site event published to subscribers. IDs are increasing and unique within process
+type Message struct {
+	ID       uint64       `json:"id"`
+	Type     string       `json:"type"`
+	Site     string       `json:"site"`
+	Tags     []string     `json:"tags,omitempty"`
+	State    sites.State  `json:"state"`
+	Previous sites.State  `json:"previous,omitempty"`
+	Result   sites.Result `json:"result"`
+}
+
+// BrokerOpts configures broker
+type BrokerOpts struct {
+	// BufferSize is a number of the latest messages kept to resume subscriptions, 1000 by default
+	BufferSize int
+	// SubscriberBuffer is a number of messages subscriber may lag behind before it's dropped, 64 by default
+	SubscriberBuffer int
+}
+
+// Broker receives asker events and fans them out to subscribers
+type Broker struct {
+	lock   sync.Mutex
+	opts   BrokerOpts
+	lastID uint64
+	buffer []Message
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription receives messages matching it's filter. C is closed once subscriber
+// is unsubscribed, dropped for lagging behind or broker is closed
+type Subscription struct {
+	C <-chan Message
+
+	ch      chan Message
+	filter  func(Message) bool
+	dropped bool
+}
+
+// Dropped reports whether subscription was closed because subscriber lagged behind.
+// It's safe to call once C is closed
+func (s *Subscription) Dropped() bool {
+	return s.dropped
+}
+
+func NewBroker(opts BrokerOpts) *Broker {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.SubscriberBuffer <= 0 {
+		opts.SubscriberBuffer = 64
+	}
+
+	return &Broker{
+		opts:   opts,
+		buffer: make([]Message, 0, opts.BufferSize),
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// OnEvent publishes result message and state message if site state has changed
+func (b *Broker) OnEvent(ev asker.Event) {
+	b.publish(Message{Type: TypeResult, Site: ev.Site, Tags: ev.Tags, State: ev.Current, Result: ev.Result})
+	if ev.Changed() {
+		b.publish(Message{Type: TypeState, Site: ev.Site, Tags: ev.Tags, State: ev.Current, Previous: ev.Previous, Result: ev.Result})
+	}
+}
+
+// Subscribe registers subscriber of messages matching filter, all messages are matched if filter is nil.
+// Buffered messages published after lastID are returned to be replayed before received ones.
+// If some of them aren't buffered anymore or lastID is unknown, e.g. issued before restart,
+// a single reset message with the latest ID is returned instead
+func (b *Broker) Subscribe(lastID uint64, filter func(Message) bool) (*Subscription, []Message) {
+	ch := make(chan Message, b.opts.SubscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		close(ch)
+		return sub, nil
+	}
+	b.subs[sub] = struct{}{}
+
+	var replay []Message
+	if lastID > 0 && b.missed(lastID) {
+		replay = append(replay, Message{ID: b.lastID, Type: TypeReset})
+	} else if lastID > 0 {
+		for _, msg := range b.buffer {
+			if msg.ID > lastID && sub.match(msg) {
+				replay = append(replay, msg)
+			}
+		}
+	}
+
+	return sub, replay
+}
+
+// missed reports whether messages after lastID can't be replayed from buffer
+func (b *Broker) missed(lastID uint64) bool {
+	if lastID > b.lastID {
+		return true
+	}
+	return len(b.buffer) > 0 && lastID < b.buffer[0].ID-1
+}
+
+// Unsubscribe closes subscription, it's safe to unsubscribe more than once
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Close closes all subscriptions
+func (b *Broker) Close() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func (b *Broker) publish(msg Message) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.lastID++
+	msg.ID = b.lastID
+
+	if len(b.buffer) == b.opts.BufferSize {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:len(b.buffer)-1]
+	}
+	b.buffer = append(b.buffer, msg)
+
+	for sub := range b.subs {
+		if !sub.match(msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			// slow subscriber is dropped rather than blocking checks, it may resume from buffer
+			sub.dropped = true
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (s *Subscription) match(msg Message) bool {
+	return s.filter == nil || s.filter(msg)
+}
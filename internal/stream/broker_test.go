@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+func event(site string, prev, cur sites.State) asker.Event {
+	return asker.Event{
+		Site:     site,
+		Previous: prev,
+		Current:  cur,
+		Result:   sites.Result{CheckedAt: time.Now(), Alive: cur == sites.StateUp},
+	}
+}
+
+func receive(t *testing.T, sub *Subscription, n int) []Message {
+	var res []Message
+	for i := 0; i < n; i++ {
+		select {
+		case msg := <-sub.C:
+			res = append(res, msg)
+		case <-time.After(time.Second):
+			t.Fatalf("%d of %d messages received", i, n)
+		}
+	}
+	return res
+}
+
+func TestBroker(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	all, replay := b.Subscribe(0, nil)
+	assert.Empty(t, replay)
+	google, _ := b.Subscribe(0, siteFilter([]string{"google.com"}))
+
+	b.OnEvent(event("google.com", sites.StateUnknown, sites.StateUp))
+	b.OnEvent(event("vk.com", sites.StateUp, sites.StateDown))
+	b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+
+	msgs := receive(t, all, 4)
+	assert.Equal(t, uint64(1), msgs[0].ID)
+	assert.Equal(t, TypeResult, msgs[0].Type)
+	assert.Equal(t, "google.com", msgs[0].Site)
+	assert.Equal(t, sites.StateUp, msgs[0].State)
+
+	// state change is published after result
+	assert.Equal(t, TypeResult, msgs[1].Type)
+	assert.Equal(t, TypeState, msgs[2].Type)
+	assert.Equal(t, "vk.com", msgs[2].Site)
+	assert.Equal(t, sites.StateUp, msgs[2].Previous)
+	assert.Equal(t, sites.StateDown, msgs[2].State)
+	assert.Equal(t, uint64(4), msgs[3].ID)
+
+	msgs = receive(t, google, 2)
+	assert.Equal(t, []uint64{1, 4}, []uint64{msgs[0].ID, msgs[1].ID})
+
+	b.Unsubscribe(google)
+	b.Unsubscribe(google)
+	_, ok := <-google.C
+	assert.False(t, ok)
+	assert.False(t, google.Dropped())
+
+	b.Close()
+	_, ok = <-all.C
+	assert.False(t, ok)
+
+	// subscription to closed broker is closed right away
+	sub, _ := b.Subscribe(0, nil)
+	_, ok = <-sub.C
+	assert.False(t, ok)
+}
+
+func TestBroker_Resume(t *testing.T) {
+	b := NewBroker(BrokerOpts{BufferSize: 3})
+	for i := 0; i < 5; i++ {
+		b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+	}
+	b.OnEvent(event("vk.com", sites.StateUp, sites.StateUp))
+
+	// only the latest messages are buffered
+	_, replay := b.Subscribe(3, nil)
+	assert.Equal(t, 3, len(replay))
+	assert.Equal(t, uint64(4), replay[0].ID)
+	assert.Equal(t, uint64(6), replay[2].ID)
+
+	// missed messages aren't buffered anymore
+	_, replay = b.Subscribe(2, nil)
+	assert.Equal(t, []Message{{ID: 6, Type: TypeReset}}, replay)
+
+	// id issued before restart
+	_, replay = b.Subscribe(42, nil)
+	assert.Equal(t, []Message{{ID: 6, Type: TypeReset}}, replay)
+
+	_, replay = b.Subscribe(6, nil)
+	assert.Empty(t, replay)
+
+	sub, replay := b.Subscribe(4, siteFilter([]string{"google.com"}))
+	assert.Equal(t, 1, len(replay))
+	assert.Equal(t, uint64(5), replay[0].ID)
+
+	// replayed messages aren't received twice
+	b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+	msgs := receive(t, sub, 1)
+	assert.Equal(t, uint64(7), msgs[0].ID)
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	b := NewBroker(BrokerOpts{SubscriberBuffer: 2})
+	slow, _ := b.Subscribe(0, nil)
+	fast, _ := b.Subscribe(0, nil)
+
+	for i := 0; i < 3; i++ {
+		b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+		receive(t, fast, 1)
+	}
+
+	msgs := receive(t, slow, 2)
+	assert.Equal(t, uint64(2), msgs[1].ID)
+	_, ok := <-slow.C
+	assert.False(t, ok)
+	assert.True(t, slow.Dropped())
+
+	b.OnEvent(event("google.com", sites.StateUp, sites.StateUp))
+	assert.Equal(t, uint64(4), receive(t, fast, 1)[0].ID)
+}
@@ -0,0 +1,184 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// wsWriteTimeout limits a single message write to client
+const wsWriteTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{}
+
+// wsRequest is a client command. Action is "subscribe" or "unsubscribe", "*" site stands for all sites
+type wsRequest struct {
+	Action string   `json:"action"`
+	Sites  []string `json:"sites"`
+	Tags   []string `json:"tags"`
+}
+
+// wsReply is sent in response to every client command
+type wsReply struct {
+	Type  string   `json:"type"`
+	Sites []string `json:"sites"`
+	Tags  []string `json:"tags"`
+	Error string   `json:"error,omitempty"`
+}
+
+// subscriptions is a set of sites and tags client is subscribed to
+type subscriptions struct {
+	lock  sync.RWMutex
+	sites map[string]bool
+	tags  map[string]bool
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{sites: make(map[string]bool), tags: make(map[string]bool)}
+}
+
+func (s *subscriptions) match(msg Message) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.sites["*"] || s.sites[msg.Site] {
+		return true
+	}
+	for _, tag := range msg.Tags {
+		if s.tags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// apply applies client command and returns reply listing current subscriptions
+func (s *subscriptions) apply(req wsRequest) wsReply {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reply := wsReply{Type: "subscriptions"}
+	switch req.Action {
+	case "subscribe":
+		for _, site := range req.Sites {
+			s.sites[site] = true
+		}
+		for _, tag := range req.Tags {
+			s.tags[tag] = true
+		}
+	case "unsubscribe":
+		for _, site := range req.Sites {
+			delete(s.sites, site)
+		}
+		for _, tag := range req.Tags {
+			delete(s.tags, tag)
+		}
+	default:
+		reply.Type = "error"
+		reply.Error = fmt.Sprintf("Unknown action %q", req.Action)
+	}
+
+	reply.Sites = keys(s.sites)
+	reply.Tags = keys(s.tags)
+	return reply
+}
+
+// WS upgrades connection to websocket and pushes messages of sites and tags client subscribes to.
+// Client lagging behind is disconnected so it never blocks checks
+func (r *resource) WS(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// upgrader responds with error itself
+		return
+	}
+	defer conn.Close()
+
+	subs := newSubscriptions()
+	sub, _ := r.broker.Subscribe(0, subs.match)
+	defer r.broker.Unsubscribe(sub)
+
+	// client is considered gone if it doesn't respond to pings
+	pongWait := 2 * r.heartbeat
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	replies := make(chan wsReply)
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(closed)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+
+			var reply wsReply
+			var req wsRequest
+			if err := json.Unmarshal(data, &req); err != nil {
+				reply = wsReply{Type: "error", Error: fmt.Sprintf("Invalid command: %v", err)}
+			} else {
+				reply = subs.apply(req)
+			}
+
+			select {
+			case replies <- reply:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(r.heartbeat)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-closed:
+			return
+		case reply := <-replies:
+			err = write(conn, reply)
+		case msg, ok := <-sub.C:
+			if !ok {
+				closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down")
+				if sub.Dropped() {
+					closeMsg = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client is too slow")
+				}
+				_ = conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(wsWriteTimeout))
+				return
+			}
+			err = write(conn, msg)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func write(conn *websocket.Conn, v interface{}) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(v)
+}
+
+func keys(set map[string]bool) []string {
+	res := make([]string, 0, len(set))
+	for k := range set {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,116 @@
+package stream
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mullakhmetov/status-board/internal/asker"
+	"github.com/mullakhmetov/status-board/internal/sites"
+)
+
+func dialWS(t *testing.T, b *Broker) (*websocket.Conn, func()) {
+	r := gin.New()
+	res := resource{broker: b, heartbeat: time.Hour}
+	r.GET("/ws", res.WS)
+	ts := httptest.NewServer(r)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	assert.NoError(t, err)
+
+	return conn, func() {
+		conn.Close()
+		ts.Close()
+	}
+}
+
+func command(t *testing.T, conn *websocket.Conn, req wsRequest) wsReply {
+	assert.NoError(t, conn.WriteJSON(req))
+
+	var reply wsReply
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	assert.NoError(t, conn.ReadJSON(&reply))
+	return reply
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) Message {
+	var msg Message
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	assert.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func taggedEvent(site string, tags ...string) asker.Event {
+	ev := event(site, sites.StateUp, sites.StateUp)
+	ev.Tags = tags
+	return ev
+}
+
+func TestWS(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	conn, teardown := dialWS(t, b)
+	defer teardown()
+
+	reply := command(t, conn, wsRequest{Action: "subscribe", Sites: []string{"google.com"}, Tags: []string{"db"}})
+	assert.Equal(t, wsReply{Type: "subscriptions", Sites: []string{"google.com"}, Tags: []string{"db"}}, reply)
+
+	b.OnEvent(taggedEvent("vk.com", "web"))
+	b.OnEvent(taggedEvent("google.com", "web"))
+	b.OnEvent(taggedEvent("postgres", "db"))
+
+	msg := readMessage(t, conn)
+	assert.Equal(t, "google.com", msg.Site)
+	assert.Equal(t, uint64(2), msg.ID)
+	msg = readMessage(t, conn)
+	assert.Equal(t, "postgres", msg.Site)
+	assert.Equal(t, []string{"db"}, msg.Tags)
+
+	reply = command(t, conn, wsRequest{Action: "unsubscribe", Sites: []string{"google.com"}})
+	assert.Equal(t, wsReply{Type: "subscriptions", Sites: []string{}, Tags: []string{"db"}}, reply)
+
+	b.OnEvent(taggedEvent("google.com", "web"))
+	b.OnEvent(taggedEvent("redis", "db"))
+	assert.Equal(t, "redis", readMessage(t, conn).Site)
+
+	reply = command(t, conn, wsRequest{Action: "subscribe", Sites: []string{"*"}})
+	assert.Equal(t, []string{"*"}, reply.Sites)
+	b.OnEvent(taggedEvent("vk.com"))
+	assert.Equal(t, "vk.com", readMessage(t, conn).Site)
+}
+
+func TestWS_InvalidCommand(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	conn, teardown := dialWS(t, b)
+	defer teardown()
+
+	reply := command(t, conn, wsRequest{Action: "watch"})
+	assert.Equal(t, "error", reply.Type)
+	assert.Equal(t, `Unknown action "watch"`, reply.Error)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+	var r wsReply
+	assert.NoError(t, conn.ReadJSON(&r))
+	assert.Equal(t, "error", r.Type)
+	assert.True(t, strings.HasPrefix(r.Error, "Invalid command"))
+
+	// connection is still usable
+	reply = command(t, conn, wsRequest{Action: "subscribe", Tags: []string{"db"}})
+	assert.Equal(t, "subscriptions", reply.Type)
+}
+
+func TestWS_Close(t *testing.T) {
+	b := NewBroker(BrokerOpts{})
+	conn, teardown := dialWS(t, b)
+	defer teardown()
+
+	command(t, conn, wsRequest{Action: "subscribe", Sites: []string{"*"}})
+	b.Close()
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}